@@ -5,4 +5,13 @@ public class Event : BaseEntity
     public required string EventType { get; set; }
     public required string EventData { get; set; }
     public bool Processed { get; set; } = false;
+
+    // Envelope added by the collector when the event was received
+    public Guid? EventId { get; set; }
+    public string? Server { get; set; }
+    public DateTimeOffset? ReceivedAt { get; set; }
+    public long? Sequence { get; set; }
+
+    // Deterministic hash of the event content, unique so duplicates are skipped
+    public string? Fingerprint { get; set; }
 }
@@ -13,5 +13,8 @@ internal class EventConfiguration : IEntityTypeConfiguration<Event>
 
         builder.Property(e => e.Processed)
             .HasDefaultValue(false);
+
+        builder.HasIndex(e => e.Fingerprint)
+            .IsUnique();
     }
 }
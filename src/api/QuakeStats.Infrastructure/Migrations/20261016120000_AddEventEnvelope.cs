@@ -0,0 +1,59 @@
+﻿using System;
+using Microsoft.EntityFrameworkCore.Migrations;
+
+#nullable disable
+
+namespace QuakeStats.Infrastructure.Migrations
+{
+    /// <inheritdoc />
+    public partial class AddEventEnvelope : Migration
+    {
+        /// <inheritdoc />
+        protected override void Up(MigrationBuilder migrationBuilder)
+        {
+            migrationBuilder.AddColumn<Guid>(
+                name: "event_id",
+                table: "events",
+                type: "uuid",
+                nullable: true);
+
+            migrationBuilder.AddColumn<DateTimeOffset>(
+                name: "received_at",
+                table: "events",
+                type: "timestamp with time zone",
+                nullable: true);
+
+            migrationBuilder.AddColumn<long>(
+                name: "sequence",
+                table: "events",
+                type: "bigint",
+                nullable: true);
+
+            migrationBuilder.AddColumn<string>(
+                name: "server",
+                table: "events",
+                type: "text",
+                nullable: true);
+        }
+
+        /// <inheritdoc />
+        protected override void Down(MigrationBuilder migrationBuilder)
+        {
+            migrationBuilder.DropColumn(
+                name: "event_id",
+                table: "events");
+
+            migrationBuilder.DropColumn(
+                name: "received_at",
+                table: "events");
+
+            migrationBuilder.DropColumn(
+                name: "sequence",
+                table: "events");
+
+            migrationBuilder.DropColumn(
+                name: "server",
+                table: "events");
+        }
+    }
+}
@@ -0,0 +1,38 @@
+﻿using Microsoft.EntityFrameworkCore.Migrations;
+
+#nullable disable
+
+namespace QuakeStats.Infrastructure.Migrations
+{
+    /// <inheritdoc />
+    public partial class AddEventFingerprint : Migration
+    {
+        /// <inheritdoc />
+        protected override void Up(MigrationBuilder migrationBuilder)
+        {
+            migrationBuilder.AddColumn<string>(
+                name: "fingerprint",
+                table: "events",
+                type: "text",
+                nullable: true);
+
+            migrationBuilder.CreateIndex(
+                name: "ix_events_fingerprint",
+                table: "events",
+                column: "fingerprint",
+                unique: true);
+        }
+
+        /// <inheritdoc />
+        protected override void Down(MigrationBuilder migrationBuilder)
+        {
+            migrationBuilder.DropIndex(
+                name: "ix_events_fingerprint",
+                table: "events");
+
+            migrationBuilder.DropColumn(
+                name: "fingerprint",
+                table: "events");
+        }
+    }
+}
@@ -42,7 +42,8 @@ public class EventProcessingService(
 
         var pendingEvents = await dbContext.Events
             .Where(e => !e.Processed)
-            .OrderBy(e => e.CreatedAt)
+            .OrderBy(e => e.ReceivedAt ?? e.CreatedAt)
+            .ThenBy(e => e.Sequence)
             .Take(10) // Process in batches to avoid large transactions
             .ToListAsync(stoppingToken);
 
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Backpressure policies applied by Submit when the event channel is full
+const (
+	BackpressureBlock      = "block"       // Wait for room, stalling the collectors
+	BackpressureDropOldest = "drop_oldest" // Discard the oldest queued event
+	BackpressureDropNewest = "drop_newest" // Discard the incoming event
+	BackpressureSpill      = "spill"       // Write events to disk until the channel drains
+)
+
+// Spilled events are written to the spool in segments of spillSegmentEvents
+// events, or once the oldest one has waited spillSegmentMaxAge, so the spill
+// path is not bound by a file and directory sync per processor batch
+const (
+	spillSegmentEvents = 1000
+	spillSegmentMaxAge = time.Second
+)
+
+// spillQueue holds events that did not fit in the event channel. While it is
+// active every new event is spilled too, so events are processed in the
+// order they were received.
+type spillQueue struct {
+	spool   *Spool
+	batch   int // Events written to the spool per segment
+	mu      sync.Mutex
+	active  bool
+	pending []Event   // Spilled events not yet written to the spool
+	since   time.Time // When the oldest pending event was spilled
+}
+
+// submitWithPolicy sends an event to the channel, applying the backpressure
+// policy when it is full
+func (p *EventProcessor) submitWithPolicy(e Event) {
+	switch p.policy {
+	case BackpressureDropNewest:
+		select {
+		case p.eventChan <- e:
+		default:
+			p.dropped.Add(1)
+		}
+	case BackpressureDropOldest:
+		for {
+			select {
+			case p.eventChan <- e:
+				return
+			default:
+			}
+			select {
+			case <-p.eventChan:
+				p.dropped.Add(1)
+			default:
+			}
+		}
+	case BackpressureSpill:
+		p.spillEvent(e)
+	default:
+		p.eventChan <- e
+	}
+}
+
+// spillEvent sends an event to the channel unless it is full or earlier
+// events are still spilled, in which case the event is spilled as well
+func (p *EventProcessor) spillEvent(e Event) {
+	q := p.spill
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.active {
+		select {
+		case p.eventChan <- e:
+			return
+		default:
+			q.active = true
+		}
+	}
+
+	if len(q.pending) == 0 {
+		q.since = time.Now()
+	}
+	q.pending = append(q.pending, e)
+	p.spilled.Add(1)
+	if len(q.pending) >= q.batch {
+		p.writeSpillLocked()
+	}
+}
+
+// writeSpillLocked writes pending spilled events to the spool. Must be called
+// with the spill lock held.
+func (p *EventProcessor) writeSpillLocked() {
+	q := p.spill
+	if len(q.pending) == 0 {
+		return
+	}
+	if err := q.spool.Append(q.pending); err != nil {
+		log.Printf("Error spilling %d events to disk, dropping them: %v", len(q.pending), err)
+		p.dropped.Add(int64(len(q.pending)))
+	}
+	q.pending = nil
+}
+
+// drainSpill processes spilled events, oldest first, once the channel is
+// empty. It runs on the processing goroutine so spilled events go through
+// the same filters, consumers and buffer as any other event. Segments are
+// popped from the spool before they are handled, so collectors spilling
+// events are not held up by a slow flush.
+func (p *EventProcessor) drainSpill(ctx context.Context) {
+	q := p.spill
+
+	// Pending events that waited too long are written out as a segment
+	q.mu.Lock()
+	if len(q.pending) > 0 && time.Since(q.since) >= spillSegmentMaxAge {
+		p.writeSpillLocked()
+	}
+	q.mu.Unlock()
+
+	for ctx.Err() == nil && len(p.eventChan) == 0 {
+		if q.spool.Pending() > 0 {
+			for _, e := range q.spool.Pop() {
+				p.handle(e)
+			}
+			continue
+		}
+
+		q.mu.Lock()
+		if q.spool.Pending() > 0 {
+			// A segment was written while the lock was released
+			q.mu.Unlock()
+			continue
+		}
+		events := q.pending
+		q.pending = nil
+		q.active = false
+		q.mu.Unlock()
+
+		for _, e := range events {
+			p.handle(e)
+		}
+		return
+	}
+}
+
+// closeSpill writes events still held in memory to the spool so they are
+// processed after a restart
+func (p *EventProcessor) closeSpill() {
+	q := p.spill
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	p.writeSpillLocked()
+}
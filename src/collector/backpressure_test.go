@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sequencedEvent returns an event whose DATA carries its sequence number
+func sequencedEvent(seq int) Event {
+	return Event{Type: "PLAYER_KILL", Sequence: uint64(seq), Data: json.RawMessage(fmt.Sprintf(`{"TIME":%d}`, seq))}
+}
+
+func TestBackpressureDropNewest(t *testing.T) {
+	processor := NewEventProcessor(Config{BatchSize: 10, FlushIntervalSec: 30, EventChannelSize: 2,
+		BackpressurePolicy: BackpressureDropNewest}, nil)
+
+	for i := 1; i <= 5; i++ {
+		processor.Submit(sequencedEvent(i))
+	}
+
+	if dropped := processor.GetMetrics()["backpressure_dropped"]; dropped != int64(3) {
+		t.Errorf("Expected 3 dropped events, got %v", dropped)
+	}
+	if first := <-processor.eventChan; first.Sequence != 1 {
+		t.Errorf("Expected the oldest event to be kept, got %d", first.Sequence)
+	}
+}
+
+func TestBackpressureDropOldest(t *testing.T) {
+	processor := NewEventProcessor(Config{BatchSize: 10, FlushIntervalSec: 30, EventChannelSize: 2,
+		BackpressurePolicy: BackpressureDropOldest}, nil)
+
+	for i := 1; i <= 5; i++ {
+		processor.Submit(sequencedEvent(i))
+	}
+
+	if dropped := processor.GetMetrics()["backpressure_dropped"]; dropped != int64(3) {
+		t.Errorf("Expected 3 dropped events, got %v", dropped)
+	}
+	if first, second := <-processor.eventChan, <-processor.eventChan; first.Sequence != 4 || second.Sequence != 5 {
+		t.Errorf("Expected the newest events to be kept, got %d and %d", first.Sequence, second.Sequence)
+	}
+}
+
+func TestBackpressureUnknownPolicyBlocks(t *testing.T) {
+	processor := NewEventProcessor(Config{BatchSize: 10, FlushIntervalSec: 30, BackpressurePolicy: "panic"}, nil)
+	if processor.policy != BackpressureBlock {
+		t.Errorf("Expected unknown policy to fall back to %s, got %s", BackpressureBlock, processor.policy)
+	}
+	if cap(processor.eventChan) != defaultEventChannelSize {
+		t.Errorf("Expected default channel size %d, got %d", defaultEventChannelSize, cap(processor.eventChan))
+	}
+}
+
+// recordingClient collects stored events for testing
+type recordingClient struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recordingClient) StoreEvents(events []Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, events...)
+	return nil
+}
+
+func (r *recordingClient) Close() error {
+	return nil
+}
+
+func (r *recordingClient) GetMetrics() map[string]interface{} {
+	return nil
+}
+
+// stored returns the number of events stored so far
+func (r *recordingClient) stored() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events)
+}
+
+// waitStored waits until n events were stored
+func waitStored(t *testing.T, client *recordingClient, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for client.stored() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %d stored events, got %d", n, client.stored())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBackpressureSpillKeepsOrder(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{BatchSize: 4, FlushIntervalSec: 30, EventChannelSize: 3,
+		BackpressurePolicy: BackpressureSpill, SpillPath: dir, SpillMaxSizeMB: 10}
+	client := &recordingClient{}
+	processor := NewEventProcessor(cfg, client)
+	processor.spill.batch = 4 // Write several segments
+
+	// The processor is not running, so everything beyond the channel is spilled
+	for i := 1; i <= 20; i++ {
+		processor.Submit(sequencedEvent(i))
+	}
+	if spilled := processor.GetMetrics()["backpressure_spilled"]; spilled != int64(17) {
+		t.Fatalf("Expected 17 spilled events, got %v", spilled)
+	}
+	if pending := processor.spill.spool.Pending(); pending != 4 {
+		t.Fatalf("Expected 4 spilled segments, got %d", pending)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go processor.Process(ctx)
+
+	// Events submitted while draining queue behind the spilled ones
+	for i := 21; i <= 24; i++ {
+		processor.Submit(sequencedEvent(i))
+	}
+
+	waitStored(t, client, 24)
+	for i, e := range client.events {
+		if e.Sequence != uint64(i+1) {
+			t.Fatalf("Expected events in order, got %d at position %d", e.Sequence, i)
+		}
+	}
+	if dropped := processor.GetMetrics()["backpressure_dropped"]; dropped != int64(0) {
+		t.Errorf("Expected no dropped events, got %v", dropped)
+	}
+}
+
+func TestBackpressureSpillWritesStaleEvents(t *testing.T) {
+	cfg := Config{BatchSize: 4, FlushIntervalSec: 30, EventChannelSize: 1,
+		BackpressurePolicy: BackpressureSpill, SpillPath: t.TempDir(), SpillMaxSizeMB: 10}
+	processor := NewEventProcessor(cfg, &recordingClient{})
+
+	for i := 1; i <= 6; i++ {
+		processor.Submit(sequencedEvent(i))
+	}
+	// Fewer events than a segment stay in memory
+	if pending := processor.spill.spool.Pending(); pending != 0 {
+		t.Fatalf("Expected no spilled segment yet, got %d", pending)
+	}
+
+	// Once they waited long enough they are written in one segment, even
+	// while the channel is full
+	processor.spill.since = time.Now().Add(-spillSegmentMaxAge)
+	processor.drainSpill(context.Background())
+	if pending := processor.spill.spool.Pending(); pending != 1 {
+		t.Errorf("Expected 1 spilled segment, got %d", pending)
+	}
+}
+
+func TestBackpressureSpillSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{BatchSize: 4, FlushIntervalSec: 30, EventChannelSize: 1,
+		BackpressurePolicy: BackpressureSpill, SpillPath: dir, SpillMaxSizeMB: 10}
+
+	processor := NewEventProcessor(cfg, &recordingClient{})
+	for i := 1; i <= 6; i++ {
+		processor.Submit(sequencedEvent(i))
+	}
+	// Shutting down persists spilled events still held in memory
+	processor.closeSpill()
+
+	client := &recordingClient{}
+	restarted := NewEventProcessor(cfg, client)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go restarted.Process(ctx)
+
+	// The event that was in the channel is lost with the old process
+	waitStored(t, client, 4)
+	for i, e := range client.events[:4] {
+		if e.Sequence != uint64(i+2) {
+			t.Fatalf("Expected spilled events in order, got %d at position %d", e.Sequence, i)
+		}
+	}
+}
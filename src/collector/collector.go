@@ -3,12 +3,15 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
+	"sync"
 )
 
 // CollectorError represents an error from a collector
 type CollectorError struct {
 	Message     string
 	Recoverable bool
+	AuthFailed  bool // The server rejected the collector's credentials
 }
 
 func (e *CollectorError) Error() string {
@@ -22,14 +25,16 @@ type Collector interface {
 }
 
 // CollectorFactory is a function type for creating collectors
-type CollectorFactory func(config *Config, processor EventProcessorInterface) (Collector, error)
+type CollectorFactory func(server ServerConfig, processor EventProcessorInterface) (Collector, error)
 
-// CollectorManager manages the lifecycle of collectors
+// CollectorManager manages the lifecycle of collectors, one per configured server
 type CollectorManager struct {
-	config         *Config
-	processor      EventProcessorInterface
+	config          *Config
+	processor       EventProcessorInterface
 	createCollector CollectorFactory
-	currentCollector Collector
+	servers         []ServerConfig
+	collectors      []Collector
+	mu              sync.Mutex
 }
 
 // EventProcessorInterface interface for processing events
@@ -40,23 +45,32 @@ type EventProcessorInterface interface {
 
 // NewCollectorManager creates a new collector manager
 func NewCollectorManager(config *Config, processor EventProcessorInterface, factory CollectorFactory) (*CollectorManager, error) {
+	servers := config.ServerList()
+	if err := validateServers(servers); err != nil {
+		return nil, err
+	}
+
 	manager := &CollectorManager{
-		config:         config,
-		processor:      processor,
+		config:          config,
+		processor:       processor,
 		createCollector: factory,
+		servers:         servers,
+		collectors:      make([]Collector, len(servers)),
 	}
 	
-	// Create initial collector
-	collector, err := factory(config, processor)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create collector: %w", err)
+	// Create initial collectors
+	for i, server := range servers {
+		collector, err := factory(server, processor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create collector for server %s: %w", server.Name, err)
+		}
+		manager.collectors[i] = collector
 	}
 	
-	manager.currentCollector = collector
 	return manager, nil
 }
 
-// Run starts the collector manager
+// Run starts the collector manager and blocks until every collector has exited
 func (m *CollectorManager) Run(ctx context.Context) {
 	// Start the event processor
 	processorCtx, processorCancel := context.WithCancel(ctx)
@@ -64,29 +78,44 @@ func (m *CollectorManager) Run(ctx context.Context) {
 	
 	go m.processor.Run(processorCtx)
 	
-	// Main collector loop with restart capability
+	// Supervise each server independently so one failing server
+	// does not affect the others
+	var wg sync.WaitGroup
+	for i := range m.servers {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			m.supervise(ctx, idx)
+		}(i)
+	}
+	
+	wg.Wait()
+}
+
+// supervise runs the collector for a single server with restart capability
+func (m *CollectorManager) supervise(ctx context.Context, idx int) {
+	server := m.servers[idx]
+	
 	for {
+		collector := m.collector(idx)
+		
 		// Check if context is done
 		select {
 		case <-ctx.Done():
-			if m.currentCollector != nil {
-				m.currentCollector.Stop()
-			}
+			collector.Stop()
 			return
 		default:
 			// Continue running
 		}
 		
 		// Run the current collector
-		err := m.currentCollector.Run(ctx)
+		err := collector.Run(ctx)
 		
 		// If context is done, exit gracefully
 		select {
 		case <-ctx.Done():
 			// Make sure to explicitly stop the collector before returning
-			if m.currentCollector != nil {
-				m.currentCollector.Stop()
-			}
+			collector.Stop()
 			return
 		default:
 			// Continue to potential restart
@@ -96,22 +125,40 @@ func (m *CollectorManager) Run(ctx context.Context) {
 		if err != nil {
 			collectorErr, ok := err.(*CollectorError)
 			if ok && collectorErr.Recoverable {
+				log.Printf("Collector for server %s failed, restarting: %v", server.Name, err)
+				
 				// Create a new collector
-				newCollector, createErr := m.createCollector(m.config, m.processor)
+				newCollector, createErr := m.createCollector(server, m.processor)
 				if createErr != nil {
-					// Failed to create new collector, exit
+					// Failed to create new collector, give up on this server
+					log.Printf("Failed to recreate collector for server %s: %v", server.Name, createErr)
 					return
 				}
 				
 				// Replace the current collector
-				m.currentCollector = newCollector
+				m.setCollector(idx, newCollector)
 			} else {
-				// Non-recoverable error, exit
+				// Non-recoverable error, give up on this server
+				log.Printf("Collector for server %s stopped: %v", server.Name, err)
 				return
 			}
 		} else {
-			// Collector exited without error, exit manager
+			// Collector exited without error
 			return
 		}
 	}
-} 
\ No newline at end of file
+}
+
+// collector returns the current collector for the server at idx
+func (m *CollectorManager) collector(idx int) Collector {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.collectors[idx]
+}
+
+// setCollector replaces the current collector for the server at idx
+func (m *CollectorManager) setCollector(idx int, collector Collector) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.collectors[idx] = collector
+}
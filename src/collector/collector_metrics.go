@@ -0,0 +1,205 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CollectorMetrics are the Prometheus metrics of the collector pipeline:
+// events per server and type, processor flushes and storage sinks
+type CollectorMetrics struct {
+	Registry *Registry
+
+	eventsReceived  *prometheus.CounterVec
+	eventsDecoded   *prometheus.CounterVec
+	eventsFailed    *prometheus.CounterVec
+	eventsInvalid   *prometheus.CounterVec
+	eventsProcessed *prometheus.CounterVec
+	flushDuration   prometheus.Histogram
+	sinkWrites      *prometheus.CounterVec
+	sinkEvents      *prometheus.CounterVec
+	sinkDuration    *prometheus.HistogramVec
+
+	mu    sync.Mutex
+	sinks []*instrumentedClient
+}
+
+// NewCollectorMetrics creates the collector metrics in a new registry
+func NewCollectorMetrics() *CollectorMetrics {
+	r := NewRegistry()
+	m := &CollectorMetrics{
+		Registry: r,
+		eventsReceived: r.NewCounterVec("events_received_total",
+			"Messages received from a server.", "server"),
+		eventsDecoded: r.NewCounterVec("events_decoded_total",
+			"Messages decoded into valid events.", "server"),
+		eventsFailed: r.NewCounterVec("events_failed_total",
+			"Messages rejected and sent to the dead-letter sink, by reason.", "server", "reason"),
+		eventsInvalid: r.NewCounterVec("events_invalid_total",
+			"Events of a known type stored although their data did not decode.", "server", "type"),
+		eventsProcessed: r.NewCounterVec("events_processed_total",
+			"Events accepted by the processor after filtering and deduplication.", "server", "type"),
+		flushDuration: r.NewHistogram("flush_duration_seconds",
+			"Time taken to store a batch flushed by the processor.", defaultLatencyBuckets),
+		sinkWrites: r.NewCounterVec("sink_writes_total",
+			"Batches written to a storage sink, by result.", "sink", "result"),
+		sinkEvents: r.NewCounterVec("sink_events_total",
+			"Events written successfully to a storage sink.", "sink"),
+		sinkDuration: r.NewHistogramVec("sink_write_duration_seconds",
+			"Time taken by a storage sink to write a batch.", defaultLatencyBuckets, "sink"),
+	}
+
+	r.NewGaugeFunc("sink_connection_active",
+		"Whether a storage sink with a connection (e.g. PostgreSQL) is connected.",
+		m.sinkConnectionSamples, "sink")
+	r.NewGaugeFunc("sink_circuit_state",
+		"Circuit breaker state of a storage sink, 1 for the current state.",
+		m.sinkCircuitSamples, "sink", "state")
+
+	return m
+}
+
+// eventReceived counts a message received from a server
+func (m *CollectorMetrics) eventReceived(server string) {
+	if m != nil {
+		m.eventsReceived.WithLabelValues(server).Inc()
+	}
+}
+
+// eventDecoded counts a message that decoded into a valid event
+func (m *CollectorMetrics) eventDecoded(server string) {
+	if m != nil {
+		m.eventsDecoded.WithLabelValues(server).Inc()
+	}
+}
+
+// eventFailed counts a rejected message
+func (m *CollectorMetrics) eventFailed(server, reason string) {
+	if m != nil {
+		m.eventsFailed.WithLabelValues(server, reason).Inc()
+	}
+}
+
+// eventInvalid counts an event stored although its data did not decode
+func (m *CollectorMetrics) eventInvalid(server, eventType string) {
+	if m != nil {
+		m.eventsInvalid.WithLabelValues(server, eventType).Inc()
+	}
+}
+
+// observeFlush records the time taken to store a flushed batch
+func (m *CollectorMetrics) observeFlush(d time.Duration) {
+	if m != nil {
+		m.flushDuration.Observe(d.Seconds())
+	}
+}
+
+// ConsumeEvent implements the EventConsumer interface, counting the events
+// accepted by the processor per server and type
+func (m *CollectorMetrics) ConsumeEvent(e Event) {
+	m.eventsProcessed.WithLabelValues(e.Server, e.Type).Inc()
+}
+
+// InstrumentSink wraps a storage client so its writes are measured under the
+// given sink name
+func (m *CollectorMetrics) InstrumentSink(name string, client DBClient) DBClient {
+	if m == nil {
+		return client
+	}
+	sink := &instrumentedClient{name: name, next: client, metrics: m, tracker: newSinkTracker(name)}
+	m.mu.Lock()
+	m.sinks = append(m.sinks, sink)
+	m.mu.Unlock()
+	return sink
+}
+
+// sinkConnectionSamples reports the connection state of sinks that have one
+func (m *CollectorMetrics) sinkConnectionSamples() []GaugeSample {
+	m.mu.Lock()
+	sinks := append([]*instrumentedClient(nil), m.sinks...)
+	m.mu.Unlock()
+
+	var samples []GaugeSample
+	for _, sink := range sinks {
+		active, ok := sink.next.GetMetrics()["connection_active"].(bool)
+		if !ok {
+			continue
+		}
+		value := 0.0
+		if active {
+			value = 1
+		}
+		samples = append(samples, GaugeSample{Labels: []string{sink.name}, Value: value})
+	}
+	return samples
+}
+
+// sinkCircuitSamples reports the circuit breaker state of sinks that have one
+func (m *CollectorMetrics) sinkCircuitSamples() []GaugeSample {
+	m.mu.Lock()
+	sinks := append([]*instrumentedClient(nil), m.sinks...)
+	m.mu.Unlock()
+
+	var samples []GaugeSample
+	for _, sink := range sinks {
+		state, ok := sink.next.GetMetrics()["circuit_state"].(string)
+		if !ok {
+			continue
+		}
+		for _, s := range []string{CircuitClosed, CircuitOpen, CircuitHalfOpen} {
+			value := 0.0
+			if s == state {
+				value = 1
+			}
+			samples = append(samples, GaugeSample{Labels: []string{sink.name, s}, Value: value})
+		}
+	}
+	return samples
+}
+
+// instrumentedClient is a DBClient decorator recording write results and
+// latency of the wrapped sink
+type instrumentedClient struct {
+	name    string
+	next    DBClient
+	metrics *CollectorMetrics
+	tracker *sinkTracker
+}
+
+// StoreEvents implements the DBClient interface
+func (c *instrumentedClient) StoreEvents(events []Event) error {
+	start := time.Now()
+	err := c.next.StoreEvents(events)
+	c.metrics.sinkDuration.WithLabelValues(c.name).Observe(time.Since(start).Seconds())
+	c.tracker.record(err)
+
+	if err != nil {
+		c.metrics.sinkWrites.WithLabelValues(c.name, "error").Inc()
+		return err
+	}
+	c.metrics.sinkWrites.WithLabelValues(c.name, "success").Inc()
+	c.metrics.sinkEvents.WithLabelValues(c.name).Add(float64(len(events)))
+	return nil
+}
+
+// SinkName returns the name the sink is measured under
+func (c *instrumentedClient) SinkName() string {
+	return c.name
+}
+
+// SinkHealth implements the SinkHealthReporter interface
+func (c *instrumentedClient) SinkHealth() []SinkHealth {
+	return []SinkHealth{c.tracker.snapshot()}
+}
+
+// Close implements the DBClient interface
+func (c *instrumentedClient) Close() error {
+	return c.next.Close()
+}
+
+// GetMetrics implements the DBClient interface
+func (c *instrumentedClient) GetMetrics() map[string]interface{} {
+	return c.next.GetMetrics()
+}
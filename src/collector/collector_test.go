@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 )
@@ -10,16 +11,31 @@ import (
 type mockEventProcessor struct {
 	processedEvents []Event
 	running         bool
+	mu              sync.Mutex
 }
 
 func (m *mockEventProcessor) ProcessEvent(event Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.processedEvents = append(m.processedEvents, event)
 }
 
 func (m *mockEventProcessor) Run(ctx context.Context) {
-	m.running = true
+	m.setRunning(true)
 	<-ctx.Done()
-	m.running = false
+	m.setRunning(false)
+}
+
+func (m *mockEventProcessor) setRunning(running bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.running = running
+}
+
+func (m *mockEventProcessor) isRunning() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.running
 }
 
 // mockCollectorImplementation for testing
@@ -32,6 +48,7 @@ type mockCollectorImplementation struct {
 	processor    EventProcessorInterface
 	shouldFail   bool
 	failOnCreate bool
+	mu           sync.Mutex
 }
 
 func newMockCollectorImplementation(processor EventProcessorInterface, shouldFail, failOnCreate bool) *mockCollectorImplementation {
@@ -44,11 +61,14 @@ func newMockCollectorImplementation(processor EventProcessorInterface, shouldFai
 }
 
 func (m *mockCollectorImplementation) Run(ctx context.Context) error {
+	m.mu.Lock()
 	if m.shouldFail {
+		m.mu.Unlock()
 		return m.runError
 	}
 	
 	m.started = true
+	m.mu.Unlock()
 	select {
 	case <-ctx.Done():
 		m.Stop() // Make sure Stop is called when context is cancelled
@@ -59,28 +79,75 @@ func (m *mockCollectorImplementation) Run(ctx context.Context) error {
 }
 
 func (m *mockCollectorImplementation) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if !m.stopped {
 		m.stopped = true
 		close(m.stopChan)
 	}
 }
 
+// fail makes the next Run return err
+func (m *mockCollectorImplementation) fail(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shouldFail = true
+	m.runError = err
+}
+
+func (m *mockCollectorImplementation) isStarted() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.started
+}
+
+func (m *mockCollectorImplementation) isStopped() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stopped
+}
+
 // mockCollectorFactory for testing
 type mockCollectorFactory struct {
 	collectors []*mockCollectorImplementation
+	servers    []string
 	shouldFail bool
 	failOnCreate bool
+	mu         sync.Mutex
 }
 
-func (f *mockCollectorFactory) CreateCollector(config *Config, processor EventProcessorInterface) (Collector, error) {
+func (f *mockCollectorFactory) CreateCollector(server ServerConfig, processor EventProcessorInterface) (Collector, error) {
 	mockCollector := newMockCollectorImplementation(processor, f.shouldFail, f.failOnCreate)
 	if mockCollector.failOnCreate {
 		return nil, &CollectorError{Message: "Failed to create collector"}
 	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	f.collectors = append(f.collectors, mockCollector)
+	f.servers = append(f.servers, server.Name)
 	return mockCollector, nil
 }
 
+func (f *mockCollectorFactory) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.collectors)
+}
+
+// collector returns the i-th collector created by the factory
+func (f *mockCollectorFactory) collector(i int) *mockCollectorImplementation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.collectors[i]
+}
+
+// server returns the server name of the i-th collector
+func (f *mockCollectorFactory) server(i int) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.servers[i]
+}
+
 func TestCollectorManagerCreation(t *testing.T) {
 	// Test valid creation
 	config := &Config{}
@@ -96,8 +163,8 @@ func TestCollectorManagerCreation(t *testing.T) {
 		t.Fatal("Manager should not be nil")
 	}
 	
-	if len(factory.collectors) != 1 {
-		t.Fatalf("Expected 1 collector to be created, got %d", len(factory.collectors))
+	if factory.count() != 1 {
+		t.Fatalf("Expected 1 collector to be created, got %d", factory.count())
 	}
 }
 
@@ -135,12 +202,12 @@ func TestCollectorManagerRun(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 	
 	// Verify processor is running
-	if !processor.running {
+	if !processor.isRunning() {
 		t.Fatal("Processor should be running")
 	}
 	
 	// Verify collector was started
-	if !factory.collectors[0].started {
+	if !factory.collector(0).isStarted() {
 		t.Fatal("Collector should be started")
 	}
 	
@@ -158,7 +225,7 @@ func TestCollectorManagerRun(t *testing.T) {
 	}
 	
 	// Verify collector was stopped
-	if !factory.collectors[0].stopped {
+	if !factory.collector(0).isStopped() {
 		t.Fatal("Collector should be stopped")
 	}
 }
@@ -172,8 +239,7 @@ func TestCollectorManagerRestart(t *testing.T) {
 	manager, _ := NewCollectorManager(config, processor, factory.CreateCollector)
 	
 	// Set up the first collector to fail after running
-	factory.collectors[0].shouldFail = true
-	factory.collectors[0].runError = &CollectorError{Message: "Test failure", Recoverable: true}
+	factory.collector(0).fail(&CollectorError{Message: "Test failure", Recoverable: true})
 	
 	// Run the manager in a goroutine
 	ctx, cancel := context.WithCancel(context.Background())
@@ -189,13 +255,13 @@ func TestCollectorManagerRestart(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 	
 	// Trigger failure by stopping the first collector
-	factory.collectors[0].Stop()
+	factory.collector(0).Stop()
 	
 	// Give it time to create a new collector
 	time.Sleep(100 * time.Millisecond)
 	
 	// Verify a second collector was created
-	if len(factory.collectors) < 2 {
+	if factory.count() < 2 {
 		t.Fatal("Expected a second collector to be created after failure")
 	}
 	
@@ -209,4 +275,81 @@ func TestCollectorManagerRestart(t *testing.T) {
 	case <-time.After(1 * time.Second):
 		t.Fatal("Manager did not exit after context cancellation")
 	}
-} 
\ No newline at end of file
+} 
+func TestCollectorManagerMultipleServers(t *testing.T) {
+	// Test one collector per configured server
+	config := &Config{
+		Servers: []ServerConfig{
+			{Name: "ca", Endpoint: "tcp://localhost:27960"},
+			{Name: "duel", Endpoint: "tcp://localhost:27961"},
+		},
+	}
+	processor := &mockEventProcessor{}
+	factory := &mockCollectorFactory{}
+	
+	manager, err := NewCollectorManager(config, processor, factory.CreateCollector)
+	if err != nil {
+		t.Fatalf("Failed to create collector manager: %v", err)
+	}
+	
+	if factory.count() != 2 {
+		t.Fatalf("Expected 2 collectors to be created, got %d", factory.count())
+	}
+	
+	if factory.server(0) != "ca" || factory.server(1) != "duel" {
+		t.Fatalf("Expected collectors for servers ca and duel, got %s and %s", factory.server(0), factory.server(1))
+	}
+	
+	// Make the first server fail so only it gets restarted
+	factory.collector(0).fail(&CollectorError{Message: "Test failure", Recoverable: true})
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	
+	done := make(chan struct{})
+	go func() {
+		manager.Run(ctx)
+		close(done)
+	}()
+	
+	// Give it time to start and restart the failed collector
+	time.Sleep(100 * time.Millisecond)
+	
+	if factory.count() != 3 {
+		t.Fatalf("Expected 3 collectors after one restart, got %d", factory.count())
+	}
+	
+	restarted := factory.server(2)
+	if restarted != "ca" {
+		t.Fatalf("Expected the ca collector to be restarted, got %s", restarted)
+	}
+	
+	// The healthy server keeps its original collector
+	if duel := factory.collector(1); !duel.isStarted() || duel.isStopped() {
+		t.Fatal("Collector for duel should still be running")
+	}
+	
+	cancel()
+	
+	select {
+	case <-done:
+		// Success
+	case <-time.After(1 * time.Second):
+		t.Fatal("Manager did not exit after context cancellation")
+	}
+}
+
+func TestCollectorManagerDuplicateServers(t *testing.T) {
+	config := &Config{
+		Servers: []ServerConfig{
+			{Name: "ca", Endpoint: "tcp://localhost:27960"},
+			{Name: "ca", Endpoint: "tcp://localhost:27961"},
+		},
+	}
+	factory := &mockCollectorFactory{}
+	
+	_, err := NewCollectorManager(config, &mockEventProcessor{}, factory.CreateCollector)
+	if err == nil {
+		t.Fatal("Expected error for duplicate server names, got nil")
+	}
+}
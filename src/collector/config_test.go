@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -76,4 +77,110 @@ func TestConfigEnvOverrides(t *testing.T) {
 	if config.PostgresTable != "test_events" {
 		t.Errorf("Postgres table not overridden by environment variable, got %s", config.PostgresTable)
 	}
-} 
\ No newline at end of file
+} 
+func TestConfigServers(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+servers:
+  - name: ca
+    endpoint: tcp://127.0.0.1:27960
+    receive_timeout_ms: 250
+    receive_hwm: 5000
+  - endpoint: tcp://127.0.0.1:27961
+zmq_receive_hwm: 2000
+`
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config := loadConfigFile(configFile)
+
+	if len(config.Servers) != 2 {
+		t.Fatalf("Expected 2 servers, got %d", len(config.Servers))
+	}
+
+	if config.Servers[0].Name != "ca" || config.Servers[0].ReceiveTimeoutMs != 250 || config.Servers[0].ReceiveHWM != 5000 {
+		t.Errorf("Unexpected first server: %+v", config.Servers[0])
+	}
+
+	// Servers without their own receive HWM use zmq_receive_hwm
+	if config.Servers[1].ReceiveHWM != 2000 {
+		t.Errorf("Expected receive HWM 2000 for second server, got %d", config.Servers[1].ReceiveHWM)
+	}
+
+	// Servers without a name are named after their endpoint
+	if config.Servers[1].Name != "tcp://127.0.0.1:27961" {
+		t.Errorf("Expected unnamed server to use its endpoint as name, got %s", config.Servers[1].Name)
+	}
+}
+
+func TestConfigServersFallback(t *testing.T) {
+	config := Config{ZmqEndpoint: "tcp://127.0.0.1:27960"}
+
+	servers := config.ServerList()
+	if len(servers) != 1 {
+		t.Fatalf("Expected 1 server, got %d", len(servers))
+	}
+
+	if servers[0].Endpoint != config.ZmqEndpoint {
+		t.Errorf("Expected fallback server to use zmq_endpoint, got %s", servers[0].Endpoint)
+	}
+}
+
+func TestServerPasswordResolution(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "zmq_password")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("Failed to write secrets file: %v", err)
+	}
+	os.Setenv("TEST_ZMQ_PASSWORD", "from-env")
+	defer os.Unsetenv("TEST_ZMQ_PASSWORD")
+
+	testCases := []struct {
+		name     string
+		server   ServerConfig
+		expected string
+	}{
+		{"inline", ServerConfig{Password: "inline"}, "inline"},
+		{"env", ServerConfig{Password: "inline", PasswordEnv: "TEST_ZMQ_PASSWORD"}, "from-env"},
+		{"file", ServerConfig{PasswordEnv: "TEST_ZMQ_PASSWORD", PasswordFile: secretFile}, "from-file"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			password, err := tc.server.resolvePassword()
+			if err != nil {
+				t.Fatalf("Failed to resolve password: %v", err)
+			}
+			if password != tc.expected {
+				t.Errorf("Expected password %q, got %q", tc.expected, password)
+			}
+		})
+	}
+}
+
+func TestConfigFilters(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+filters:
+  - name: warmup
+    warmup: true
+  - name: noise
+    types: [PLAYER_MEDAL, PLAYER_SWITCHTEAM]
+    servers: [duel]
+`
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config := loadConfigFile(configFile)
+
+	if len(config.Filters) != 2 {
+		t.Fatalf("Expected 2 filter rules, got %d", len(config.Filters))
+	}
+	if !config.Filters[0].Warmup {
+		t.Errorf("Expected warmup rule, got %+v", config.Filters[0])
+	}
+	if len(config.Filters[1].Types) != 2 || config.Filters[1].Servers[0] != "duel" {
+		t.Errorf("Unexpected noise rule: %+v", config.Filters[1])
+	}
+}
@@ -4,12 +4,32 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lib/pq" // PostgreSQL driver
 )
 
+// Ways PostgresClient can insert a batch of events
+const (
+	PostgresInsertRow      = "row"      // One INSERT per event
+	PostgresInsertMultiRow = "multirow" // One INSERT per multiRowInsertSize events
+	PostgresInsertCopy     = "copy"     // COPY FROM STDIN
+)
+
+// multiRowInsertSize is the number of events per multi-row INSERT, keeping
+// well below the PostgreSQL limit of 65535 parameters per statement
+const multiRowInsertSize = 1000
+
+// copyStagingTable is the temporary table COPY writes to before events are
+// moved into the events table
+const copyStagingTable = "events_staging"
+
+// eventColumns are the columns written for every event, in eventArgs order.
+// The fingerprint column has a unique index so duplicates are skipped.
+var eventColumns = []string{"event_type", "event_data", "event_id", "server", "received_at", "sequence", "fingerprint"}
+
 // DBClient interface for database operations
 type DBClient interface {
 	StoreEvents(events []Event) error
@@ -25,13 +45,17 @@ type PostgresClient struct {
 	lastActivity    time.Time
 	connectionMutex sync.Mutex
 	closed          bool
+	activeWrites    int // StoreEvents calls using db, which must not be closed as idle
 	idleTimeout     time.Duration
+	insertMode      string
+	maxConns        int
 	checkTimer      *time.Timer
 	// Connection metrics
 	connectCount        int
 	disconnectCount     int
 	reconnectCount      int
 	totalEventsStored   int
+	duplicatesSkipped   int
 	lastConnectTime     time.Time
 	lastDisconnectTime  time.Time
 }
@@ -47,11 +71,35 @@ func NewPostgresClient(cfg Config) (DBClient, error) {
 		config:       cfg,
 		lastActivity: time.Now(),
 		idleTimeout:  time.Duration(cfg.PostgresIdleTimeoutMin) * time.Minute,
+		insertMode:   cfg.PostgresInsertMode,
+		maxConns:     cfg.PostgresMaxConns,
+	}
+	if client.maxConns <= 0 {
+		client.maxConns = 1
+	}
+	switch client.insertMode {
+	case PostgresInsertRow, PostgresInsertMultiRow, PostgresInsertCopy:
+	default:
+		log.Printf("Warning: Unknown postgres_insert_mode %q, using %s", client.insertMode, PostgresInsertRow)
+		client.insertMode = PostgresInsertRow
 	}
 
-	// Connect immediately for the first time
-	if err := client.connect(); err != nil {
-		return nil, err
+	// Connect immediately for the first time, retrying briefly so the
+	// collector can start alongside the database
+	var err error
+	retryDelay := 500 * time.Millisecond
+	for attempt := 1; attempt <= initialConnectAttempts; attempt++ {
+		if err = client.connect(); err == nil {
+			break
+		}
+		log.Printf("Failed to connect to database (attempt %d/%d): %v", attempt, initialConnectAttempts, err)
+		if attempt < initialConnectAttempts {
+			time.Sleep(retryDelay)
+			retryDelay *= 2 // Exponential backoff
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database after %d attempts: %w", initialConnectAttempts, err)
 	}
 
 	// Start the connection checker
@@ -61,11 +109,20 @@ func NewPostgresClient(cfg Config) (DBClient, error) {
 	return client, nil
 }
 
+// initialConnectAttempts is the number of connection attempts at startup.
+// Later writes make a single attempt; retries are left to ResilientClient.
+const initialConnectAttempts = 3
+
 // connect establishes a connection to the database
 func (p *PostgresClient) connect() error {
 	p.connectionMutex.Lock()
 	defer p.connectionMutex.Unlock()
+	return p.connectLocked()
+}
 
+// connectLocked establishes a connection to the database in a single
+// attempt. Must be called with connectionMutex held.
+func (p *PostgresClient) connectLocked() error {
 	if p.db != nil && !p.closed {
 		// Connection already exists and is not marked as closed
 		return nil
@@ -79,41 +136,20 @@ func (p *PostgresClient) connect() error {
 	// Use the connection string directly
 	connectionString := p.config.PostgresConnectionString
 
-	// Try to connect with exponential backoff
-	var db *sql.DB
-	var err error
-	maxRetries := 3
-	retryDelay := 500 * time.Millisecond
-
-	for i := 0; i < maxRetries; i++ {
-		db, err = sql.Open("postgres", connectionString)
-		if err != nil {
-			log.Printf("Failed to open database connection (attempt %d/%d): %v", i+1, maxRetries, err)
-			time.Sleep(retryDelay)
-			retryDelay *= 2 // Exponential backoff
-			continue
-		}
-
-		// Test the connection
-		if err := db.Ping(); err != nil {
-			log.Printf("Failed to ping database (attempt %d/%d): %v", i+1, maxRetries, err)
-			db.Close()
-			time.Sleep(retryDelay)
-			retryDelay *= 2 // Exponential backoff
-			continue
-		}
-
-		// Connection successful
-		break
+	db, err := sql.Open("postgres", connectionString)
+	if err != nil {
+		return fmt.Errorf("failed to open database connection: %w", err)
 	}
 
-	if err != nil {
-		return fmt.Errorf("failed to connect to database after %d attempts: %w", maxRetries, err)
+	// Test the connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	// Set connection pool parameters
-	db.SetMaxOpenConns(1)
-	db.SetMaxIdleConns(1)
+	db.SetMaxOpenConns(p.maxConns)
+	db.SetMaxIdleConns(p.maxConns)
 	db.SetConnMaxLifetime(time.Hour)
 
 	p.db = db
@@ -147,7 +183,7 @@ func (p *PostgresClient) checkAndCloseIdleConnection() {
 	}
 
 	idleTime := time.Since(p.lastActivity)
-	if idleTime > p.idleTimeout {
+	if idleTime > p.idleTimeout && p.activeWrites == 0 {
 		log.Printf("Closing idle database connection after %v of inactivity", idleTime.Round(time.Second))
 		p.db.Close()
 		p.db = nil
@@ -171,50 +207,198 @@ func (p *PostgresClient) StoreEvents(events []Event) error {
 	// Ensure we have a connection
 	p.connectionMutex.Lock()
 	if p.db == nil || p.closed {
-		if err := p.connect(); err != nil {
+		if err := p.connectLocked(); err != nil {
 			p.connectionMutex.Unlock()
 			return fmt.Errorf("failed to connect to database: %w", err)
 		}
 	}
-	// Update last activity time
+	// Update last activity time. The pool is copied so a reconnect or Close
+	// by another goroutine does not swap it out from under this write.
 	p.lastActivity = time.Now()
+	p.activeWrites++
+	db := p.db
 	p.connectionMutex.Unlock()
 
+	defer func() {
+		p.connectionMutex.Lock()
+		p.activeWrites--
+		p.lastActivity = time.Now()
+		p.connectionMutex.Unlock()
+	}()
+
 	// Begin transaction
-	tx, err := p.db.Begin()
+	tx, err := db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback() // Will be no-op if transaction is committed
 
-	// Prepare the insert statement
-	stmt, err := tx.Prepare(fmt.Sprintf(
-		"INSERT INTO %s (event_type, event_data) VALUES ($1, $2)",
-		p.tableName,
-	))
+	// Insert the events, skipping those already stored
+	var inserted int64
+	switch p.insertMode {
+	case PostgresInsertCopy:
+		inserted, err = p.copyEvents(tx, events)
+	case PostgresInsertRow:
+		inserted, err = p.insertEventsPerRow(tx, events)
+	default:
+		inserted, err = p.insertEventsMultiRow(tx, events)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Update metrics
+	duplicates := len(events) - int(inserted)
+	p.connectionMutex.Lock()
+	p.totalEventsStored += int(inserted)
+	p.duplicatesSkipped += duplicates
+	p.connectionMutex.Unlock()
+
+	if duplicates > 0 {
+		log.Printf("Stored %d events in PostgreSQL database (%d duplicates skipped)", inserted, duplicates)
+	} else {
+		log.Printf("Stored %d events in PostgreSQL database", inserted)
+	}
+	return nil
+}
+
+// insertEventsPerRow inserts events with one INSERT statement per event and
+// returns the number of rows inserted
+func (p *PostgresClient) insertEventsPerRow(tx *sql.Tx, events []Event) (int64, error) {
+	stmt, err := tx.Prepare(multiRowInsertQuery(p.tableName, 1))
 	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	// Insert each event
+	var inserted int64
 	for _, event := range events {
-		_, err := stmt.Exec(event.Type, event.Data)
+		result, err := stmt.Exec(eventArgs(event)...)
 		if err != nil {
-			return fmt.Errorf("failed to insert event: %w", err)
+			return 0, fmt.Errorf("failed to insert event: %w", err)
 		}
+		inserted += rowsAffected(result)
 	}
+	return inserted, nil
+}
 
-	// Commit the transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+// insertEventsMultiRow inserts events with multi-row INSERT statements of up
+// to multiRowInsertSize events each and returns the number of rows inserted
+func (p *PostgresClient) insertEventsMultiRow(tx *sql.Tx, events []Event) (int64, error) {
+	var inserted int64
+	for start := 0; start < len(events); start += multiRowInsertSize {
+		end := start + multiRowInsertSize
+		if end > len(events) {
+			end = len(events)
+		}
+		chunk := events[start:end]
+
+		args := make([]interface{}, 0, len(chunk)*len(eventColumns))
+		for _, event := range chunk {
+			args = append(args, eventArgs(event)...)
+		}
+		result, err := tx.Exec(multiRowInsertQuery(p.tableName, len(chunk)), args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert events: %w", err)
+		}
+		inserted += rowsAffected(result)
 	}
+	return inserted, nil
+}
 
-	// Update metrics
-	p.totalEventsStored += len(events)
+// multiRowInsertQuery builds an INSERT statement for the given number of
+// events that skips events whose fingerprint is already stored
+func multiRowInsertQuery(tableName string, rows int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES ", tableName, strings.Join(eventColumns, ", "))
+	param := 1
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j := range eventColumns {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "$%d", param)
+			param++
+		}
+		b.WriteByte(')')
+	}
+	b.WriteString(" ON CONFLICT DO NOTHING")
+	return b.String()
+}
 
-	log.Printf("Stored %d events in PostgreSQL database", len(events))
-	return nil
+// copyEvents streams events with COPY FROM STDIN into a temporary staging
+// table, since COPY cannot skip conflicting rows, then moves them into the
+// events table. It returns the number of rows inserted.
+func (p *PostgresClient) copyEvents(tx *sql.Tx, events []Event) (int64, error) {
+	columns := strings.Join(eventColumns, ", ")
+	if _, err := tx.Exec(fmt.Sprintf(
+		"CREATE TEMP TABLE %s ON COMMIT DROP AS SELECT %s FROM %s WITH NO DATA",
+		copyStagingTable, columns, p.tableName,
+	)); err != nil {
+		return 0, fmt.Errorf("failed to create staging table: %w", err)
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn(copyStagingTable, eventColumns...))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare copy: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, event := range events {
+		args := eventArgs(event)
+		// COPY sends []byte as bytea, event_data is a text column
+		args[1] = string(event.Data)
+		if _, err := stmt.Exec(args...); err != nil {
+			return 0, fmt.Errorf("failed to copy event: %w", err)
+		}
+	}
+
+	// Flush the buffered rows
+	if _, err := stmt.Exec(); err != nil {
+		return 0, fmt.Errorf("failed to copy events: %w", err)
+	}
+
+	result, err := tx.Exec(fmt.Sprintf(
+		"INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT DO NOTHING",
+		p.tableName, columns, columns, copyStagingTable,
+	))
+	if err != nil {
+		return 0, fmt.Errorf("failed to move copied events: %w", err)
+	}
+	return rowsAffected(result), nil
+}
+
+// rowsAffected returns the number of rows affected by a statement, or 0 when
+// the driver cannot tell
+func rowsAffected(result sql.Result) int64 {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// eventArgs returns the column values of an event. Envelope columns are
+// nullable so events without an envelope (e.g. old backups) are stored as NULL
+func eventArgs(event Event) []interface{} {
+	return []interface{}{
+		event.Type,
+		event.Data,
+		sql.NullString{String: event.ID, Valid: event.ID != ""},
+		sql.NullString{String: event.Server, Valid: event.Server != ""},
+		sql.NullTime{Time: event.ReceivedAt, Valid: !event.ReceivedAt.IsZero()},
+		sql.NullInt64{Int64: int64(event.Sequence), Valid: event.Sequence != 0},
+		event.Fingerprint(),
+	}
 }
 
 // Close closes the database connection
@@ -244,8 +428,11 @@ func (p *PostgresClient) GetMetrics() map[string]interface{} {
 		"disconnect_count":     p.disconnectCount,
 		"reconnect_count":      p.reconnectCount,
 		"total_events_stored":  p.totalEventsStored,
+		"duplicates_skipped":   p.duplicatesSkipped,
 		"connection_active":    p.db != nil && !p.closed,
 		"idle_timeout_minutes": p.idleTimeout.Minutes(),
+		"insert_mode":          p.insertMode,
+		"max_connections":      p.maxConns,
 	}
 
 	if !p.lastConnectTime.IsZero() {
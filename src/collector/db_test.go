@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMultiRowInsertQuery(t *testing.T) {
+	query := multiRowInsertQuery("events", 2)
+
+	want := "INSERT INTO events (event_type, event_data, event_id, server, received_at, sequence, fingerprint) VALUES " +
+		"($1, $2, $3, $4, $5, $6, $7), ($8, $9, $10, $11, $12, $13, $14) ON CONFLICT DO NOTHING"
+	if query != want {
+		t.Errorf("Unexpected query:\n got: %s\nwant: %s", query, want)
+	}
+
+	// A full chunk must stay below the PostgreSQL parameter limit
+	if params := multiRowInsertSize * len(eventColumns); params > 65535 {
+		t.Errorf("Multi-row insert uses %d parameters, more than PostgreSQL allows", params)
+	}
+}
+
+// benchmarkPostgresClient connects to the database in QUAKE_STATS_BENCH_POSTGRES
+// and creates a scratch events table that is dropped when the benchmark ends
+func benchmarkPostgresClient(b *testing.B, mode string) *PostgresClient {
+	connectionString := os.Getenv("QUAKE_STATS_BENCH_POSTGRES")
+	if connectionString == "" {
+		b.Skip("Set QUAKE_STATS_BENCH_POSTGRES to a connection string to run PostgreSQL benchmarks")
+	}
+
+	table := fmt.Sprintf("bench_events_%d", os.Getpid())
+	client, err := NewPostgresClient(Config{
+		PostgresEnabled:          true,
+		PostgresConnectionString: connectionString,
+		PostgresTable:            table,
+		PostgresIdleTimeoutMin:   5,
+		PostgresInsertMode:       mode,
+		PostgresMaxConns:         4,
+	})
+	if err != nil {
+		b.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	pg := client.(*PostgresClient)
+
+	if _, err := pg.db.Exec(fmt.Sprintf(`CREATE TABLE %s (
+		id BIGSERIAL PRIMARY KEY,
+		event_type TEXT NOT NULL,
+		event_data TEXT NOT NULL,
+		event_id UUID,
+		server TEXT,
+		received_at TIMESTAMPTZ,
+		sequence BIGINT,
+		fingerprint TEXT UNIQUE,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		processed BOOLEAN NOT NULL DEFAULT false
+	)`, table)); err != nil {
+		pg.Close()
+		b.Fatalf("Failed to create benchmark table: %v", err)
+	}
+
+	b.Cleanup(func() {
+		pg.db.Exec("DROP TABLE " + table)
+		pg.Close()
+	})
+	return pg
+}
+
+// benchmarkEvents returns a batch of realistic kill events. TIME starts at
+// offset so every event has its own fingerprint.
+func benchmarkEvents(offset, n int) []Event {
+	data := `{"MATCH_GUID":"d23d30e4-2137-4a33-b085-9db594e67d5a","MOD":"ROCKET","TIME":%d,"WARMUP":false,` +
+		`"KILLER":{"NAME":"alice","STEAM_ID":"76561198000000001","TEAM":1,"WEAPON":"ROCKET","POSITION":{"X":1,"Y":2,"Z":3}},` +
+		`"VICTIM":{"NAME":"bob","STEAM_ID":"76561198000000002","TEAM":2,"WEAPON":"SHOTGUN","POSITION":{"X":4,"Y":5,"Z":6}}}`
+
+	events := make([]Event, n)
+	for i := range events {
+		events[i] = Event{
+			Type:       "PLAYER_KILL",
+			Data:       json.RawMessage(fmt.Sprintf(data, offset+i)),
+			ID:         newEventID(),
+			Server:     "bench",
+			ReceivedAt: time.Now(),
+			Sequence:   uint64(i + 1),
+		}
+	}
+	return events
+}
+
+// BenchmarkPostgresStoreEvents compares the insert modes on import-sized
+// batches. Run with:
+//
+//	QUAKE_STATS_BENCH_POSTGRES=postgresql://... go test -run ^$ -bench PostgresStoreEvents
+func BenchmarkPostgresStoreEvents(b *testing.B) {
+	for _, mode := range []string{PostgresInsertRow, PostgresInsertMultiRow, PostgresInsertCopy} {
+		for _, batchSize := range []int{100, 5000} {
+			b.Run(fmt.Sprintf("%s/batch=%d", mode, batchSize), func(b *testing.B) {
+				client := benchmarkPostgresClient(b, mode)
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					events := benchmarkEvents(i*batchSize, batchSize)
+					b.StartTimer()
+					if err := client.StoreEvents(events); err != nil {
+						b.Fatalf("Failed to store events: %v", err)
+					}
+				}
+				b.ReportMetric(float64(b.N*batchSize)/b.Elapsed().Seconds(), "events/s")
+			})
+		}
+	}
+}
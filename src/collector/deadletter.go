@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Reasons an event is sent to the dead-letter sink
+const (
+	RejectMalformedJSON = "malformed_json"
+	RejectEmptyType     = "empty_type"
+	RejectUnknownType   = "unknown_type"
+	RejectMissingData   = "missing_data"
+)
+
+// deadLetterFileName is the name of the JSONL file inside the dead-letter directory
+const deadLetterFileName = "dead_letters.jsonl"
+
+// DeadLetter is a rejected payload together with the reason it was rejected
+type DeadLetter struct {
+	ID         string    `json:"id"`
+	RejectedAt time.Time `json:"rejected_at"`
+	ReceivedAt time.Time `json:"received_at"`
+	Server     string    `json:"server,omitempty"`
+	Sequence   uint64    `json:"sequence,omitempty"`
+	Type       string    `json:"type,omitempty"`
+	Reason     string    `json:"reason"`
+	Error      string    `json:"error,omitempty"`
+	Raw        []byte    `json:"raw"` // The payload exactly as received, base64 encoded
+}
+
+// DeadLetterSink stores rejected payloads for later inspection
+type DeadLetterSink interface {
+	Write(letter DeadLetter) error
+	Close() error
+}
+
+// FileDeadLetterSink appends dead letters to a JSONL file
+type FileDeadLetterSink struct {
+	path  string
+	file  *os.File
+	mu    sync.Mutex
+	count int64
+}
+
+// NewFileDeadLetterSink opens (or creates) the dead-letter file in the given directory
+func NewFileDeadLetterSink(dir string) (*FileDeadLetterSink, error) {
+	if dir == "" {
+		dir = "deadletter"
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create dead-letter directory: %w", err)
+	}
+
+	path := filepath.Join(dir, deadLetterFileName)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dead-letter file %s: %w", path, err)
+	}
+
+	log.Printf("Dead-letter sink initialized: %s", path)
+	return &FileDeadLetterSink{path: path, file: file}, nil
+}
+
+// Write appends a dead letter to the file and syncs it to disk
+func (f *FileDeadLetterSink) Write(letter DeadLetter) error {
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
+	data = append(data, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return fmt.Errorf("dead-letter file is closed")
+	}
+	if _, err := f.file.Write(data); err != nil {
+		return fmt.Errorf("failed to write dead letter: %w", err)
+	}
+	if err := f.file.Sync(); err != nil {
+		log.Printf("Warning: Failed to sync dead-letter file: %v", err)
+	}
+	f.count++
+	return nil
+}
+
+// Close closes the dead-letter file
+func (f *FileDeadLetterSink) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil {
+		err := f.file.Close()
+		f.file = nil
+		return err
+	}
+	return nil
+}
+
+// ReadDeadLetters reads all dead letters from a JSONL file
+func ReadDeadLetters(path string) ([]DeadLetter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dead-letter file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var letters []DeadLetter
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var letter DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			log.Printf("Error decoding dead letter on line %d: %v", line, err)
+			continue
+		}
+		letters = append(letters, letter)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter file %s: %w", path, err)
+	}
+	return letters, nil
+}
+
+// WriteDeadLetters replaces the contents of a dead-letter file
+func WriteDeadLetters(path string, letters []DeadLetter) error {
+	var data []byte
+	for _, letter := range letters {
+		line, err := json.Marshal(letter)
+		if err != nil {
+			return fmt.Errorf("failed to marshal dead letter: %w", err)
+		}
+		data = append(data, line...)
+		data = append(data, '\n')
+	}
+
+	tmpPath := path + ".tmp"
+	if err := writeFileSync(tmpPath, data); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace dead-letter file: %w", err)
+	}
+	return nil
+}
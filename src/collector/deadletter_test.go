@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// recordingDeadLetterSink keeps dead letters in memory for testing
+type recordingDeadLetterSink struct {
+	letters []DeadLetter
+}
+
+func (r *recordingDeadLetterSink) Write(letter DeadLetter) error {
+	r.letters = append(r.letters, letter)
+	return nil
+}
+
+func (r *recordingDeadLetterSink) Close() error {
+	return nil
+}
+
+func TestEventValidatorRejectsInvalidEvents(t *testing.T) {
+	next := &mockEventProcessor{}
+	sink := &recordingDeadLetterSink{}
+	validator := NewEventValidator(next, sink)
+
+	testCases := []struct {
+		name   string
+		event  Event
+		reason string
+	}{
+		{"valid", Event{Type: "ROUND_OVER", Data: json.RawMessage(`{"ROUND":1,"TEAM_WON":"RED"}`)}, ""},
+		{"empty type", Event{Type: "", Data: json.RawMessage(`{}`)}, RejectEmptyType},
+		{"unknown type", Event{Type: "PLAYER_DANCE", Data: json.RawMessage(`{}`)}, RejectUnknownType},
+		{"missing data", Event{Type: "ROUND_OVER"}, RejectMissingData},
+	}
+
+	for _, tc := range testCases {
+		validator.ProcessEvent(tc.event)
+	}
+
+	if len(next.processedEvents) != 1 || next.processedEvents[0].Type != "ROUND_OVER" {
+		t.Fatalf("Expected only the valid event to be processed, got %+v", next.processedEvents)
+	}
+
+	if len(sink.letters) != len(testCases)-1 {
+		t.Fatalf("Expected %d dead letters, got %d", len(testCases)-1, len(sink.letters))
+	}
+	for i, tc := range testCases[1:] {
+		if sink.letters[i].Reason != tc.reason {
+			t.Errorf("%s: expected reason %s, got %s", tc.name, tc.reason, sink.letters[i].Reason)
+		}
+	}
+
+	// The original payload is kept so it can be re-submitted
+	var raw Event
+	if err := json.Unmarshal(sink.letters[1].Raw, &raw); err != nil || raw.Type != "PLAYER_DANCE" {
+		t.Errorf("Expected raw payload to round-trip, got %s (%v)", sink.letters[1].Raw, err)
+	}
+
+	metrics := validator.GetMetrics()
+	if metrics["accepted"] != int64(1) || metrics["rejected"] != int64(3) {
+		t.Errorf("Unexpected validation metrics: %v", metrics)
+	}
+}
+
+func TestEventValidatorStoresInvalidData(t *testing.T) {
+	next := &mockEventProcessor{}
+	sink := &recordingDeadLetterSink{}
+	validator := NewEventValidator(next, sink)
+
+	// A known type whose data does not decode is stored, not quarantined
+	validator.ProcessEvent(Event{Type: "ROUND_OVER", Data: json.RawMessage(`{"ROUND":"first","TEAM_WON":"GREEN"}`)})
+
+	if len(next.processedEvents) != 1 || len(sink.letters) != 0 {
+		t.Fatalf("Expected the event to be processed, got %d processed and %d dead letters", len(next.processedEvents), len(sink.letters))
+	}
+	metrics := validator.GetMetrics()
+	if metrics["accepted"] != int64(1) || metrics["invalid_data"] != int64(1) {
+		t.Errorf("Unexpected validation metrics: %v", metrics)
+	}
+}
+
+func TestEventValidatorMalformedJSON(t *testing.T) {
+	sink := &recordingDeadLetterSink{}
+	validator := NewEventValidator(&mockEventProcessor{}, sink)
+
+	raw := []byte(`{"TYPE":"PLAYER_KILL","DATA":{`)
+	validator.Reject(raw, Event{Server: "ca", Sequence: 7}, RejectMalformedJSON, errors.New("unexpected end of JSON input"))
+
+	if len(sink.letters) != 1 {
+		t.Fatalf("Expected 1 dead letter, got %d", len(sink.letters))
+	}
+	letter := sink.letters[0]
+	if string(letter.Raw) != string(raw) || letter.Server != "ca" || letter.Sequence != 7 || letter.ID == "" {
+		t.Errorf("Unexpected dead letter: %+v", letter)
+	}
+}
+
+func TestDeadLetterResubmit(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileDeadLetterSink(dir)
+	if err != nil {
+		t.Fatalf("Failed to create dead-letter sink: %v", err)
+	}
+
+	validator := NewEventValidator(&mockEventProcessor{}, sink)
+	validator.Reject([]byte(`{"TYPE":"ROUND_OVER","DATA":{"ROUND":1}}`), Event{Server: "ca"}, RejectUnknownType, errors.New("unknown"))
+	validator.Reject([]byte(`not json`), Event{Server: "ca"}, RejectMalformedJSON, errors.New("invalid"))
+	sink.Close()
+
+	path := filepath.Join(dir, deadLetterFileName)
+	letters, err := ReadDeadLetters(path)
+	if err != nil {
+		t.Fatalf("Failed to read dead letters: %v", err)
+	}
+	if len(letters) != 2 {
+		t.Fatalf("Expected 2 dead letters, got %d", len(letters))
+	}
+
+	var stored []Event
+	mockClient := &mockDBClient{storeEventsFunc: func(events []Event) error {
+		stored = append(stored, events...)
+		return nil
+	}}
+
+	all := func(DeadLetter) bool { return true }
+	remaining, err := resubmitDeadLetters(letters, all, mockClient, 10)
+	if err != nil {
+		t.Fatalf("Failed to resubmit dead letters: %v", err)
+	}
+
+	// The now valid event is stored, the malformed one stays quarantined
+	if len(stored) != 1 || stored[0].Type != "ROUND_OVER" || stored[0].Server != "ca" {
+		t.Errorf("Unexpected stored events: %+v", stored)
+	}
+	if len(remaining) != 1 || remaining[0].Reason != RejectMalformedJSON {
+		t.Errorf("Unexpected remaining dead letters: %+v", remaining)
+	}
+
+	if err := WriteDeadLetters(path, remaining); err != nil {
+		t.Fatalf("Failed to write dead letters: %v", err)
+	}
+	letters, _ = ReadDeadLetters(path)
+	if len(letters) != 1 {
+		t.Errorf("Expected 1 dead letter after rewrite, got %d", len(letters))
+	}
+}
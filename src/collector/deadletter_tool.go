@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DeadLetterTool is a command-line tool to inspect quarantined events and
+// re-submit them to storage once the cause of the rejection has been fixed
+func DeadLetterTool() {
+	// Only run if we're specifically using the deadletter command
+	if len(os.Args) < 2 || os.Args[1] != "deadletter" {
+		return
+	}
+
+	if len(os.Args) < 3 || (os.Args[2] != "list" && os.Args[2] != "resubmit") {
+		fmt.Println("Usage: collector deadletter <list|resubmit> [flags]")
+		os.Exit(2)
+	}
+	command := os.Args[2]
+
+	// Configure deadletter flags
+	cmd := flag.NewFlagSet("deadletter "+command, flag.ExitOnError)
+	configPath := cmd.String("config", "config.yaml", "Path to configuration file")
+	filePath := cmd.String("file", "", "Path to dead-letter file (default: from configuration)")
+	reason := cmd.String("reason", "", "Only include dead letters with this rejection reason")
+	server := cmd.String("server", "", "Only include dead letters from this server")
+	eventType := cmd.String("type", "", "Only include dead letters with this event type")
+	full := cmd.Bool("full", false, "Print full raw payloads (list)")
+	batchSize := cmd.Int("batch", 100, "Batch size for storing events (resubmit)")
+	dryRun := cmd.Bool("dry-run", false, "Show what would be re-submitted without storing anything (resubmit)")
+
+	// Parse flags (skip the "deadletter <command>" args)
+	if err := cmd.Parse(os.Args[3:]); err != nil {
+		log.Fatalf("Error parsing deadletter flags: %v", err)
+	}
+
+	// Load configuration
+	cfg := loadConfigFile(*configPath)
+
+	if *filePath == "" {
+		*filePath = filepath.Join(cfg.DeadLetterPath, deadLetterFileName)
+	}
+
+	letters, err := ReadDeadLetters(*filePath)
+	if err != nil {
+		log.Fatalf("Error reading dead letters: %v", err)
+	}
+
+	filter := func(letter DeadLetter) bool {
+		return (*reason == "" || letter.Reason == *reason) &&
+			(*server == "" || letter.Server == *server) &&
+			(*eventType == "" || letter.Type == *eventType)
+	}
+
+	switch command {
+	case "list":
+		listDeadLetters(letters, filter, *full)
+	case "resubmit":
+		if *dryRun {
+			resubmitDeadLetters(letters, filter, nil, *batchSize)
+			os.Exit(0)
+		}
+
+		storageClient := newStorageClient(cfg, nil)
+		if storageClient == nil {
+			log.Fatalf("Error: No storage is enabled in the configuration")
+		}
+
+		remaining, err := resubmitDeadLetters(letters, filter, storageClient, *batchSize)
+		storageClient.Close()
+		if err != nil {
+			log.Printf("Error re-submitting dead letters: %v", err)
+		}
+		if writeErr := WriteDeadLetters(*filePath, remaining); writeErr != nil {
+			log.Fatalf("Error updating dead-letter file: %v", writeErr)
+		}
+		if err != nil {
+			os.Exit(1)
+		}
+	}
+
+	os.Exit(0)
+}
+
+// listDeadLetters prints the matching dead letters and a summary by reason
+func listDeadLetters(letters []DeadLetter, filter func(DeadLetter) bool, full bool) {
+	counts := make(map[string]int)
+	for _, letter := range letters {
+		if !filter(letter) {
+			continue
+		}
+		counts[letter.Reason]++
+
+		raw := string(letter.Raw)
+		if !full && len(raw) > 200 {
+			raw = raw[:200] + "..."
+		}
+		fmt.Printf("%s  %s  server=%s type=%s reason=%s\n",
+			letter.RejectedAt.Format("2006-01-02 15:04:05"), letter.ID, letter.Server, letter.Type, letter.Reason)
+		if letter.Error != "" {
+			fmt.Printf("    error: %s\n", letter.Error)
+		}
+		fmt.Printf("    raw:   %s\n", raw)
+	}
+
+	reasons := make([]string, 0, len(counts))
+	for r := range counts {
+		reasons = append(reasons, r)
+	}
+	sort.Strings(reasons)
+
+	fmt.Printf("\n%d of %d dead letters matched\n", sumCounts(counts), len(letters))
+	for _, r := range reasons {
+		fmt.Printf("  %-16s %d\n", r, counts[r])
+	}
+}
+
+// resubmitDeadLetters re-validates the matching dead letters and stores the
+// ones that now pass. It returns the dead letters that should be kept: those
+// not matching the filter, still failing validation, or not stored. When
+// dbClient is nil it only reports what would be re-submitted.
+func resubmitDeadLetters(letters []DeadLetter, filter func(DeadLetter) bool, dbClient DBClient, batchSize int) ([]DeadLetter, error) {
+	var remaining []DeadLetter
+	var batch []Event
+	var batchLetters []DeadLetter
+	resubmitted, stillFailing := 0, 0
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if dbClient != nil {
+			if err := dbClient.StoreEvents(batch); err != nil {
+				return err
+			}
+		}
+		resubmitted += len(batch)
+		batch = batch[:0]
+		batchLetters = batchLetters[:0]
+		return nil
+	}
+
+	for i, letter := range letters {
+		if !filter(letter) {
+			remaining = append(remaining, letter)
+			continue
+		}
+
+		event := Event{
+			ID:         letter.ID,
+			Server:     letter.Server,
+			ReceivedAt: letter.ReceivedAt,
+			Sequence:   letter.Sequence,
+		}
+		if err := json.Unmarshal(letter.Raw, &event); err != nil {
+			stillFailing++
+			remaining = append(remaining, letter)
+			continue
+		}
+		if reason, err := ValidateEvent(event); err != nil {
+			log.Printf("Dead letter %s still rejected (%s): %v", letter.ID, reason, err)
+			stillFailing++
+			remaining = append(remaining, letter)
+			continue
+		}
+
+		batch = append(batch, event)
+		batchLetters = append(batchLetters, letter)
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				// Keep the failed batch and everything after it
+				remaining = append(remaining, batchLetters...)
+				remaining = append(remaining, letters[i+1:]...)
+				return remaining, fmt.Errorf("failed to store events: %w", err)
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		remaining = append(remaining, batchLetters...)
+		return remaining, fmt.Errorf("failed to store events: %w", err)
+	}
+
+	if dbClient == nil {
+		fmt.Printf("Would re-submit %d dead letters, %d still fail validation\n", resubmitted, stillFailing)
+	} else {
+		fmt.Printf("Re-submitted %d dead letters, %d still fail validation\n", resubmitted, stillFailing)
+	}
+	return remaining, nil
+}
+
+// sumCounts returns the sum of all counts
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	return total
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+)
+
+// fingerprintFields are the DATA fields that identify an event
+type fingerprintFields struct {
+	MatchGUID string          `json:"MATCH_GUID"`
+	Time      json.RawMessage `json:"TIME"`
+}
+
+// Fingerprint returns a deterministic hash of the event content built from
+// its MATCH_GUID, TYPE, TIME and a hash of the canonical DATA. The same event
+// gets the same fingerprint whether it was received live, replayed after a
+// reconnect or imported from a backup file. The envelope is not included.
+func (e Event) Fingerprint() string {
+	var fields fingerprintFields
+	_ = json.Unmarshal(e.Data, &fields)
+	payload := sha256.Sum256(canonicalJSON(e.Data))
+
+	h := sha256.New()
+	h.Write([]byte(fields.MatchGUID))
+	h.Write([]byte{0})
+	h.Write([]byte(e.Type))
+	h.Write([]byte{0})
+	h.Write(fields.Time)
+	h.Write([]byte{0})
+	h.Write(payload[:])
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalJSON re-encodes JSON with sorted keys, no insignificant whitespace
+// and no HTML escaping, so payloads that only differ in encoding (e.g. after
+// a round-trip through a backup file) compare equal. Invalid JSON is
+// returned unchanged.
+func canonicalJSON(data []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return data
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return data
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
+// DedupeWindow remembers the fingerprints of the most recent events so
+// duplicates, such as events replayed after a reconnect, can be dropped
+// before storage
+type DedupeWindow struct {
+	mu      sync.Mutex
+	seen    map[string]struct{}
+	ring    []string
+	next    int
+	dropped int64
+}
+
+// NewDedupeWindow creates a window holding the given number of fingerprints
+func NewDedupeWindow(size int) *DedupeWindow {
+	return &DedupeWindow{
+		seen: make(map[string]struct{}, size),
+		ring: make([]string, size),
+	}
+}
+
+// Duplicate reports whether the fingerprint is already in the window and
+// counts the drop. New fingerprints are added, evicting the oldest one.
+func (d *DedupeWindow) Duplicate(fingerprint string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.seen[fingerprint]; ok {
+		d.dropped++
+		return true
+	}
+
+	if oldest := d.ring[d.next]; oldest != "" {
+		delete(d.seen, oldest)
+	}
+	d.ring[d.next] = fingerprint
+	d.next = (d.next + 1) % len(d.ring)
+	d.seen[fingerprint] = struct{}{}
+	return false
+}
+
+// Dropped returns the number of duplicates dropped
+func (d *DedupeWindow) Dropped() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dropped
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestEventFingerprint(t *testing.T) {
+	base := Event{
+		Type: "PLAYER_KILL",
+		Data: json.RawMessage(`{"MATCH_GUID":"m1","TIME":10,"KILLER":{"NAME":"<alice>"}}`),
+	}
+
+	// Same content with different encoding and envelope
+	same := Event{
+		Type:     "PLAYER_KILL",
+		Data:     json.RawMessage("{\n  \"KILLER\": {\"NAME\": \"\\u003calice\\u003e\"},\n  \"TIME\": 10, \"MATCH_GUID\": \"m1\"\n}"),
+		ID:       newEventID(),
+		Server:   "ca",
+		Sequence: 42,
+	}
+	if base.Fingerprint() != same.Fingerprint() {
+		t.Error("Expected equal fingerprints for the same content")
+	}
+
+	// A backup round-trip keeps the fingerprint
+	line, err := json.Marshal(newBackupRecord(base))
+	if err != nil {
+		t.Fatalf("Failed to marshal backup record: %v", err)
+	}
+	var record backupRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		t.Fatalf("Failed to unmarshal backup record: %v", err)
+	}
+	if record.Event().Fingerprint() != base.Fingerprint() {
+		t.Error("Expected fingerprint to survive a backup round-trip")
+	}
+
+	different := []Event{
+		{Type: "PLAYER_DEATH", Data: base.Data},
+		{Type: "PLAYER_KILL", Data: json.RawMessage(`{"MATCH_GUID":"m2","TIME":10,"KILLER":{"NAME":"<alice>"}}`)},
+		{Type: "PLAYER_KILL", Data: json.RawMessage(`{"MATCH_GUID":"m1","TIME":11,"KILLER":{"NAME":"<alice>"}}`)},
+		{Type: "PLAYER_KILL", Data: json.RawMessage(`{"MATCH_GUID":"m1","TIME":10,"KILLER":{"NAME":"bob"}}`)},
+	}
+	for _, e := range different {
+		if e.Fingerprint() == base.Fingerprint() {
+			t.Errorf("Expected different fingerprint for %s %s", e.Type, e.Data)
+		}
+	}
+}
+
+func TestDedupeWindow(t *testing.T) {
+	window := NewDedupeWindow(2)
+
+	if window.Duplicate("a") || window.Duplicate("b") {
+		t.Fatal("Expected new fingerprints not to be duplicates")
+	}
+	if !window.Duplicate("a") {
+		t.Error("Expected repeated fingerprint to be a duplicate")
+	}
+
+	// Adding c evicts a, the oldest fingerprint
+	window.Duplicate("c")
+	if window.Duplicate("a") {
+		t.Error("Expected evicted fingerprint not to be a duplicate")
+	}
+	if window.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped duplicate, got %d", window.Dropped())
+	}
+}
+
+func TestEventProcessorDropsDuplicates(t *testing.T) {
+	config := Config{
+		BatchSize:        3,
+		FlushIntervalSec: 30,
+		DedupeWindowSize: 100,
+	}
+
+	flushedEvents := make(chan []Event, 1)
+	mockClient := &mockDBClient{
+		storeEventsFunc: func(events []Event) error {
+			flushedEvents <- events
+			return nil
+		},
+	}
+
+	processor := NewEventProcessor(config, mockClient)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go processor.Process(ctx)
+
+	kill := func(time int) Event {
+		data, _ := json.Marshal(map[string]interface{}{"MATCH_GUID": "m1", "TIME": time})
+		return Event{Type: "PLAYER_KILL", Data: data, ID: newEventID()}
+	}
+
+	// The replayed kill at TIME 1 is dropped, so the batch only fills up with the third kill
+	for _, e := range []Event{kill(1), kill(2), kill(1), kill(3)} {
+		processor.Submit(e)
+	}
+
+	select {
+	case flushed := <-flushedEvents:
+		if len(flushed) != 3 {
+			t.Fatalf("Expected 3 events, got %d", len(flushed))
+		}
+		if string(flushed[2].Data) != string(kill(3).Data) {
+			t.Errorf("Expected duplicate to be dropped, got %s", flushed[2].Data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for flush")
+	}
+
+	if dropped := processor.GetMetrics()["dedupe_dropped"]; dropped != int64(1) {
+		t.Errorf("Expected 1 dropped duplicate, got %v", dropped)
+	}
+}
@@ -2,16 +2,44 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/json"
+	"fmt"
 	"log"
 	"runtime"
+	"sync/atomic"
 	"time"
+
+	"quake-stats/qlstats"
 )
 
 // Event represents a game event
 type Event struct {
 	Type string          `json:"TYPE"`
 	Data json.RawMessage `json:"DATA"`
+
+	// Collector-side envelope, filled in when the event is received
+	ID         string    `json:"-"` // Unique event ID
+	Server     string    `json:"-"` // Name of the server the event was received from
+	ReceivedAt time.Time `json:"-"` // Time the collector received the event
+	Sequence   uint64    `json:"-"` // Per-connection sequence number, starting at 1
+}
+
+// Decode decodes the event DATA into the typed model for its TYPE
+func (e Event) Decode() (qlstats.Event, error) {
+	return qlstats.Decode(e.Type, e.Data)
+}
+
+// newEventID generates a random (version 4) UUID for an event
+func newEventID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand never fails on supported platforms
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
 }
 
 // EventProcessor handles batching and processing of events
@@ -21,6 +49,18 @@ type EventProcessor struct {
 	buffer     []Event
 	bufferSize int
 	dbClient   DBClient
+	spool      *Spool
+	lastFailed time.Time // When storing or replaying a batch last failed
+	filter     *EventFilter
+	dedupe     *DedupeWindow
+	consumers  []EventConsumer
+	metrics    *CollectorMetrics
+	depth      atomic.Int64 // Buffer length, readable outside the processing loop
+	policy     string       // Backpressure policy when eventChan is full
+	spill      *spillQueue
+	dropped    atomic.Int64  // Events dropped by the backpressure policy
+	spilled    atomic.Int64  // Events spilled to disk
+	sweepEvery time.Duration // How often consumers implementing EventSweeper are swept
 	stats      struct {
 		eventsProcessed  int64
 		batchesProcessed int64
@@ -28,47 +68,222 @@ type EventProcessor struct {
 	}
 }
 
+// defaultEventChannelSize is the event channel capacity when none is configured
+const defaultEventChannelSize = 1000
+
+// consumerSweepInterval is how often consumers implementing EventSweeper are swept
+const consumerSweepInterval = time.Minute
+
 // NewEventProcessor creates a new event processor
 func NewEventProcessor(cfg Config, dbClient DBClient) *EventProcessor {
-	return &EventProcessor{
+	channelSize := cfg.EventChannelSize
+	if channelSize <= 0 {
+		channelSize = defaultEventChannelSize
+	}
+
+	processor := &EventProcessor{
 		config:     cfg,
-		eventChan:  make(chan Event, 1000),
+		eventChan:  make(chan Event, channelSize),
 		buffer:     make([]Event, 0, cfg.BatchSize),
 		bufferSize: cfg.BatchSize,
 		dbClient:   dbClient,
+		policy:     cfg.BackpressurePolicy,
+		sweepEvery: consumerSweepInterval,
 		stats: struct {
 			eventsProcessed  int64
 			batchesProcessed int64
 			lastReportTime   time.Time
 		}{0, 0, time.Now()},
 	}
+
+	// Pick how Submit behaves when the channel is full
+	switch processor.policy {
+	case BackpressureBlock, BackpressureDropOldest, BackpressureDropNewest, BackpressureSpill:
+	case "":
+		processor.policy = BackpressureBlock
+	default:
+		log.Printf("Warning: Unknown backpressure_policy %q, using %s", processor.policy, BackpressureBlock)
+		processor.policy = BackpressureBlock
+	}
+
+	if processor.policy == BackpressureSpill {
+		spool, err := NewSpool(SpoolConfig{
+			Path:     cfg.SpillPath,
+			MaxBytes: int64(cfg.SpillMaxSizeMB) * 1024 * 1024, // Convert MB to bytes
+		})
+		if err != nil {
+			log.Printf("Warning: Failed to initialize spill directory, using %s: %v", BackpressureDropNewest, err)
+			processor.policy = BackpressureDropNewest
+		} else {
+			processor.spill = &spillQueue{
+				spool: spool,
+				batch: max(cfg.BatchSize, spillSegmentEvents),
+				// Events left over from a previous run are processed before new ones
+				active: spool.Pending() > 0,
+			}
+		}
+	}
+
+	// Compile filter rules applied before buffering
+	if len(cfg.Filters) > 0 {
+		processor.filter = NewEventFilter(cfg.Filters)
+	}
+
+	// Remember recent fingerprints to drop duplicate events
+	if cfg.DedupeWindowSize > 0 {
+		processor.dedupe = NewDedupeWindow(cfg.DedupeWindowSize)
+	}
+
+	// Open the spool for batches that fail to store
+	if cfg.SpoolEnabled && dbClient != nil {
+		spool, err := NewSpool(SpoolConfig{
+			Path:       cfg.SpoolPath,
+			MaxBytes:   int64(cfg.SpoolMaxSizeMB) * 1024 * 1024, // Convert MB to bytes
+			DropOldest: cfg.SpoolDropOldest,
+		})
+		if err != nil {
+			log.Printf("Warning: Failed to initialize spool, failed batches will be dropped: %v", err)
+		} else {
+			processor.spool = spool
+		}
+	}
+
+	return processor
 }
 
 // Process starts the event processing loop
 func (p *EventProcessor) Process(ctx context.Context) {
 	ticker := time.NewTicker(time.Duration(p.config.FlushIntervalSec) * time.Second)
 	heartbeatTicker := time.NewTicker(30 * time.Second) // Fixed at 30s for simplicity
+	sweepTicker := time.NewTicker(p.sweepEvery)
 	defer ticker.Stop()
 	defer heartbeatTicker.Stop()
+	defer sweepTicker.Stop()
+
+	// Spilled events are drained whenever the channel is empty
+	var drain <-chan time.Time
+	if p.spill != nil {
+		drainTicker := time.NewTicker(spillDrainInterval)
+		defer drainTicker.Stop()
+		drain = drainTicker.C
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
 			p.flush()
+			if p.spill != nil {
+				p.closeSpill()
+			}
 			return
 		case <-ticker.C:
 			p.flush()
+			p.replaySpool()
 		case <-heartbeatTicker.C:
 			p.logHeartbeat()
+		case <-sweepTicker.C:
+			p.sweepConsumers()
+		case <-drain:
+			p.drainSpill(ctx)
 		case e := <-p.eventChan:
-			p.buffer = append(p.buffer, e)
-			if len(p.buffer) >= p.bufferSize {
-				p.flush()
-			}
+			p.handle(e)
+		}
+	}
+}
+
+// spillDrainInterval is how often spilled events are checked for draining
+const spillDrainInterval = 200 * time.Millisecond
+
+// handle filters and buffers a single event, flushing when the buffer is full
+func (p *EventProcessor) handle(e Event) {
+	if p.filter != nil && p.filter.Drop(e) {
+		return
+	}
+	if p.dedupe != nil && p.dedupe.Duplicate(e.Fingerprint()) {
+		return
+	}
+	for _, consumer := range p.consumers {
+		consumer.ConsumeEvent(e)
+	}
+	p.buffer = append(p.buffer, e)
+	p.depth.Store(int64(len(p.buffer)))
+	if len(p.buffer) >= p.bufferSize {
+		p.flush()
+	}
+}
+
+// sweepConsumers sweeps the consumers that expire state over time
+func (p *EventProcessor) sweepConsumers() {
+	for _, consumer := range p.consumers {
+		if sweeper, ok := consumer.(EventSweeper); ok {
+			sweeper.Sweep()
 		}
 	}
 }
 
+// AddConsumer registers a consumer that receives every event passing the
+// filters as it arrives, before it is buffered for storage. Must be called
+// before Process is started.
+func (p *EventProcessor) AddConsumer(consumer EventConsumer) {
+	p.consumers = append(p.consumers, consumer)
+}
+
+// SetMetrics records flush latency and per-type event counts in the given
+// metrics and exposes the processor state as gauges. Must be called before
+// Process is started.
+func (p *EventProcessor) SetMetrics(m *CollectorMetrics) {
+	p.metrics = m
+	p.AddConsumer(m)
+
+	single := func(v float64) []GaugeSample { return []GaugeSample{{Value: v}} }
+	m.Registry.NewGaugeFunc("processor_channel_length", "Events waiting in the processor channel.",
+		func() []GaugeSample { return single(float64(len(p.eventChan))) })
+	m.Registry.NewGaugeFunc("processor_channel_capacity", "Capacity of the processor channel.",
+		func() []GaugeSample { return single(float64(cap(p.eventChan))) })
+	m.Registry.NewGaugeFunc("processor_buffer_depth", "Events buffered for the next flush.",
+		func() []GaugeSample { return single(float64(p.depth.Load())) })
+	m.Registry.NewGaugeFunc("spool_pending_batches", "Failed batches waiting in the spool.",
+		func() []GaugeSample {
+			if p.spool == nil {
+				return nil
+			}
+			return single(float64(p.spool.Pending()))
+		})
+	m.Registry.NewCounterFunc("spool_dropped_events_total", "Failed events rejected or dropped because the spool was full.",
+		func() []GaugeSample {
+			if p.spool == nil {
+				return nil
+			}
+			_, events := p.spool.Dropped()
+			return single(float64(events))
+		})
+	m.Registry.NewCounterFunc("filter_dropped_total", "Events dropped by a filter rule.",
+		func() []GaugeSample {
+			if p.filter == nil {
+				return nil
+			}
+			dropped := p.filter.Dropped()
+			samples := make([]GaugeSample, 0, len(dropped))
+			for _, rule := range sortedKeys(dropped) {
+				samples = append(samples, GaugeSample{Labels: []string{rule}, Value: float64(dropped[rule])})
+			}
+			return samples
+		}, "rule")
+	m.Registry.NewCounterFunc("backpressure_dropped_total", "Events dropped because the processor channel was full.",
+		func() []GaugeSample {
+			return []GaugeSample{{Labels: []string{p.policy}, Value: float64(p.dropped.Load())}}
+		}, "policy")
+	m.Registry.NewCounterFunc("backpressure_spilled_total", "Events spilled to disk because the processor channel was full.",
+		func() []GaugeSample { return single(float64(p.spilled.Load())) })
+	m.Registry.NewCounterFunc("dedupe_dropped_total", "Duplicate events dropped by the dedupe window.",
+		func() []GaugeSample {
+			if p.dedupe == nil {
+				return nil
+			}
+			return single(float64(p.dedupe.Dropped()))
+		})
+}
+
 // Run implements the EventProcessorInterface interface for testing
 func (p *EventProcessor) Run(ctx context.Context) {
 	p.Process(ctx)
@@ -79,9 +294,16 @@ func (p *EventProcessor) ProcessEvent(e Event) {
 	p.Submit(e)
 }
 
-// Submit adds an event to the processing queue
+// Submit adds an event to the processing queue, applying the backpressure
+// policy when the queue is full
 func (p *EventProcessor) Submit(e Event) {
-	p.eventChan <- e
+	p.submitWithPolicy(e)
+}
+
+// ChannelUsage returns the number of events waiting in the processing queue
+// and its capacity
+func (p *EventProcessor) ChannelUsage() (int, int) {
+	return len(p.eventChan), cap(p.eventChan)
 }
 
 // GetChannel returns the event channel for submitting events
@@ -104,9 +326,9 @@ func (p *EventProcessor) flush() {
 	
 	// Store events in PostgreSQL if enabled
 	if p.dbClient != nil {
-		if err := p.dbClient.StoreEvents(p.buffer); err != nil {
-			log.Printf("Error storing events in PostgreSQL: %v", err)
-		}
+		start := time.Now()
+		p.store(p.buffer)
+		p.metrics.observeFlush(time.Since(start))
 	}
 	
 	// Update stats
@@ -115,6 +337,107 @@ func (p *EventProcessor) flush() {
 	
 	// Clear buffer (reuse the underlying array)
 	p.buffer = p.buffer[:0]
+	p.depth.Store(0)
+}
+
+// store writes a batch to the storage client, spooling it if the client fails.
+// While older batches are still spooled, new batches go to the spool as well
+// so events are stored in the order they were received.
+func (p *EventProcessor) store(events []Event) {
+	if p.spool == nil {
+		if err := p.dbClient.StoreEvents(events); err != nil {
+			log.Printf("Error storing events in PostgreSQL: %v", err)
+		}
+		return
+	}
+
+	if p.replaySpool() {
+		err := p.dbClient.StoreEvents(events)
+		if err == nil {
+			return
+		}
+		p.lastFailed = time.Now()
+		log.Printf("Error storing events, spooling batch: %v", err)
+	}
+
+	if err := p.spool.Append(events); err != nil {
+		log.Printf("Error spooling batch of %d events, dropping it: %v", len(events), err)
+	}
+}
+
+// Spool returns the spool for failed batches, or nil when it is disabled
+func (p *EventProcessor) Spool() *Spool {
+	return p.spool
+}
+
+// spoolReplayBatches is the most spooled batches replayed per flush, so a
+// large spool drains over several flushes instead of stalling the processor
+const spoolReplayBatches = 10
+
+// replaySpool replays up to spoolReplayBatches spooled batches. Storage is
+// not retried until the retry interval has passed since the last failure. It
+// returns true when the spool is empty afterwards.
+func (p *EventProcessor) replaySpool() bool {
+	if p.spool == nil || p.dbClient == nil {
+		return true
+	}
+	if p.spool.Pending() == 0 {
+		return true
+	}
+
+	retryInterval := time.Duration(p.config.SpoolRetryIntervalSec) * time.Second
+	if time.Since(p.lastFailed) < retryInterval {
+		return false
+	}
+
+	if _, err := p.spool.ReplayN(p.dbClient, spoolReplayBatches); err != nil {
+		p.lastFailed = time.Now()
+		log.Printf("Storage still unavailable, %d batches remain spooled: %v", p.spool.Pending(), err)
+		return false
+	}
+	return p.spool.Pending() == 0
+}
+
+// GetMetrics returns metrics about the processor, its spool and storage client
+func (p *EventProcessor) GetMetrics() map[string]interface{} {
+	metrics := map[string]interface{}{
+		"channel_length":   len(p.eventChan),
+		"channel_capacity": cap(p.eventChan),
+		"spool_enabled":    p.spool != nil,
+		"backpressure_policy":  p.policy,
+		"backpressure_dropped": p.dropped.Load(),
+	}
+
+	if p.spill != nil {
+		metrics["backpressure_spilled"] = p.spilled.Load()
+		for k, v := range p.spill.spool.GetMetrics() {
+			metrics["spill_"+k] = v
+		}
+	}
+
+	if p.spool != nil {
+		for k, v := range p.spool.GetMetrics() {
+			metrics["spool_"+k] = v
+		}
+	}
+
+	if p.filter != nil {
+		for name, count := range p.filter.Dropped() {
+			metrics["filter_dropped_"+name] = count
+		}
+	}
+
+	if p.dedupe != nil {
+		metrics["dedupe_dropped"] = p.dedupe.Dropped()
+	}
+
+	if p.dbClient != nil {
+		for k, v := range p.dbClient.GetMetrics() {
+			metrics["storage_"+k] = v
+		}
+	}
+
+	return metrics
 }
 
 // logHeartbeat logs periodic stats about the collector
@@ -133,6 +456,18 @@ func (p *EventProcessor) logHeartbeat() {
 	log.Printf("Heartbeat: Processed %.2f events/sec (%.2f batches/min), Memory: %d MB, Goroutines: %d",
 		eventsPerSecond, batchesPerMinute, m.Alloc/1024/1024, runtime.NumGoroutine())
 	
+	if p.filter != nil && p.filter.Len() > 0 {
+		log.Printf("Heartbeat: Filtered events: %s", p.filter.Summary())
+	}
+	
+	if p.dedupe != nil && p.dedupe.Dropped() > 0 {
+		log.Printf("Heartbeat: Dropped %d duplicate events", p.dedupe.Dropped())
+	}
+	
+	if p.spool != nil && p.spool.Pending() > 0 {
+		log.Printf("Heartbeat: %d batches waiting in spool", p.spool.Pending())
+	}
+	
 	// Reset stats
 	p.stats.eventsProcessed = 0
 	p.stats.batchesProcessed = 0
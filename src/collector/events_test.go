@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 )
@@ -135,6 +136,138 @@ func TestEventProcessorContextCancellation(t *testing.T) {
 	}
 }
 
+func TestEventProcessorSpoolsFailedBatches(t *testing.T) {
+	config := Config{
+		BatchSize:        1,
+		FlushIntervalSec: 30,
+		SpoolEnabled:     true,
+		SpoolPath:        t.TempDir(),
+	}
+
+	// The first store fails, later ones succeed
+	failures := 1
+	var stored []Event
+	mockClient := &mockDBClient{
+		storeEventsFunc: func(events []Event) error {
+			if failures > 0 {
+				failures--
+				return errors.New("database unavailable")
+			}
+			stored = append(stored, events...)
+			return nil
+		},
+	}
+
+	processor := NewEventProcessor(config, mockClient)
+
+	processor.buffer = append(processor.buffer, Event{Type: "MATCH_STARTED", Data: json.RawMessage(`{}`)})
+	processor.flush()
+
+	if processor.spool.Pending() != 1 {
+		t.Fatalf("Expected failed batch to be spooled, got %d pending", processor.spool.Pending())
+	}
+
+	// The next flush replays the spooled batch before the new one
+	processor.buffer = append(processor.buffer, Event{Type: "PLAYER_KILL", Data: json.RawMessage(`{}`)})
+	processor.flush()
+
+	if processor.spool.Pending() != 0 {
+		t.Fatalf("Expected spool to be empty, got %d pending", processor.spool.Pending())
+	}
+	if len(stored) != 2 || stored[0].Type != "MATCH_STARTED" || stored[1].Type != "PLAYER_KILL" {
+		t.Fatalf("Expected spooled batch to be stored first, got %+v", stored)
+	}
+
+	metrics := processor.GetMetrics()
+	if metrics["spool_batches_replayed"] != int64(1) {
+		t.Errorf("Expected 1 replayed batch in metrics, got %v", metrics["spool_batches_replayed"])
+	}
+}
+
+func TestEventProcessorSpoolReplayWaitsAndIsBounded(t *testing.T) {
+	config := Config{
+		BatchSize:             1,
+		FlushIntervalSec:      30,
+		SpoolEnabled:          true,
+		SpoolPath:             t.TempDir(),
+		SpoolRetryIntervalSec: 3600,
+	}
+
+	failing := true
+	calls := 0
+	mockClient := &mockDBClient{
+		storeEventsFunc: func(events []Event) error {
+			calls++
+			if failing {
+				return errors.New("database unavailable")
+			}
+			return nil
+		},
+	}
+	processor := NewEventProcessor(config, mockClient)
+
+	processor.buffer = append(processor.buffer, Event{Type: "MATCH_STARTED", Data: json.RawMessage(`{}`)})
+	processor.flush()
+
+	// Within the retry interval the next batch is spooled without a store
+	processor.buffer = append(processor.buffer, Event{Type: "PLAYER_KILL", Data: json.RawMessage(`{}`)})
+	processor.flush()
+	if calls != 1 || processor.spool.Pending() != 2 {
+		t.Fatalf("Expected 1 store and 2 spooled batches, got %d stores and %d spooled", calls, processor.spool.Pending())
+	}
+
+	// Once the interval has passed, a flush replays at most spoolReplayBatches
+	for i := 0; i < spoolReplayBatches; i++ {
+		processor.spool.Append(spoolTestEvents("PLAYER_DEATH"))
+	}
+	failing = false
+	processor.lastFailed = time.Time{}
+	processor.buffer = append(processor.buffer, Event{Type: "ROUND_OVER", Data: json.RawMessage(`{}`)})
+	processor.flush()
+	if pending := processor.spool.Pending(); pending != 3 {
+		t.Fatalf("Expected 3 batches left after a bounded replay, got %d", pending)
+	}
+}
+
+func TestEventProcessorFiltersBeforeBuffering(t *testing.T) {
+	config := Config{
+		BatchSize:        2,
+		FlushIntervalSec: 30,
+		Filters:          []FilterRule{{Name: "warmup", Warmup: true}},
+	}
+
+	flushedEvents := make(chan []Event, 1)
+	mockClient := &mockDBClient{
+		storeEventsFunc: func(events []Event) error {
+			flushedEvents <- append([]Event(nil), events...)
+			return nil
+		},
+	}
+
+	processor := NewEventProcessor(config, mockClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go processor.Process(ctx)
+
+	processor.Submit(Event{Type: "PLAYER_KILL", Data: json.RawMessage(`{"WARMUP":false}`)})
+	processor.Submit(Event{Type: "PLAYER_KILL", Data: json.RawMessage(`{"WARMUP":true}`)})
+	processor.Submit(Event{Type: "PLAYER_DEATH", Data: json.RawMessage(`{"WARMUP":false}`)})
+
+	select {
+	case flushed := <-flushedEvents:
+		if len(flushed) != 2 || flushed[0].Type != "PLAYER_KILL" || flushed[1].Type != "PLAYER_DEATH" {
+			t.Errorf("Expected the warmup event to be filtered, got %+v", flushed)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timed out waiting for flush")
+	}
+
+	if dropped := processor.GetMetrics()["filter_dropped_warmup"]; dropped != int64(1) {
+		t.Errorf("Expected 1 dropped event in metrics, got %v", dropped)
+	}
+}
+
 // mockDBClient for testing
 type mockDBClient struct {
 	storeEventsFunc func(events []Event) error
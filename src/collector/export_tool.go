@@ -0,0 +1,220 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ExportFilter selects the stored events to export. Zero values match
+// every event.
+type ExportFilter struct {
+	From      time.Time // Inclusive
+	To        time.Time // Exclusive
+	Types     []string
+	MatchGUID string
+	Server    string
+}
+
+// ExportTool is a command-line tool to export events from the PostgreSQL
+// events table to JSONL backup files, e.g. to move data between environments
+// or rebuild a database with import
+func ExportTool() {
+	// Only run if we're specifically using the export command
+	if len(os.Args) < 2 || os.Args[1] != "export" {
+		return
+	}
+
+	// Configure export flags
+	exportCmd := flag.NewFlagSet("export", flag.ExitOnError)
+	configPath := exportCmd.String("config", "config.yaml", "Path to configuration file")
+	dirPath := exportCmd.String("dir", "", "Directory to write event files to (required)")
+	table := exportCmd.String("table", "", "Table to export (default: postgres_table from configuration)")
+	from := exportCmd.String("from", "", "Only export events received at or after this time (RFC 3339 or YYYY-MM-DD)")
+	to := exportCmd.String("to", "", "Only export events received before this time (RFC 3339 or YYYY-MM-DD)")
+	eventTypes := exportCmd.String("type", "", "Only export events of these comma-separated types")
+	matchGUID := exportCmd.String("match-guid", "", "Only export events with this MATCH_GUID")
+	server := exportCmd.String("server", "", "Only export events from this server")
+	maxSizeMB := exportCmd.Int("max-size-mb", 0, "Rotate output files at this size (default: file_backup_max_size_mb from configuration)")
+	compression := exportCmd.String("compression", FileCompressionNone, "Compression of output files: none or gzip")
+	batchSize := exportCmd.Int("batch", 1000, "Number of events written per batch")
+
+	// Parse export flags (skip the "export" arg)
+	if err := exportCmd.Parse(os.Args[2:]); err != nil {
+		log.Fatalf("Error parsing export flags: %v", err)
+	}
+
+	// Validate required parameters
+	if *dirPath == "" {
+		log.Fatalf("Error: -dir must be specified")
+	}
+
+	filter := ExportFilter{MatchGUID: *matchGUID, Server: *server}
+	var err error
+	if filter.From, err = parseExportTime(*from); err != nil {
+		log.Fatalf("Error: Invalid -from: %v", err)
+	}
+	if filter.To, err = parseExportTime(*to); err != nil {
+		log.Fatalf("Error: Invalid -to: %v", err)
+	}
+	for _, eventType := range strings.Split(*eventTypes, ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			filter.Types = append(filter.Types, eventType)
+		}
+	}
+
+	// Load configuration
+	cfg := loadConfigFile(*configPath)
+	if *table == "" {
+		*table = cfg.PostgresTable
+	}
+	if *maxSizeMB <= 0 {
+		*maxSizeMB = cfg.FileBackupMaxSizeMB
+	}
+
+	db, err := sql.Open("postgres", cfg.PostgresConnectionString)
+	if err != nil {
+		log.Fatalf("Error connecting to PostgreSQL: %v", err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		log.Fatalf("Error connecting to PostgreSQL: %v", err)
+	}
+
+	// Files are only rotated by size, however long the export takes
+	fileClient, err := NewFileBackupClient(FileBackupConfig{
+		Enabled:     true,
+		BasePath:    *dirPath,
+		MaxFileSize: int64(*maxSizeMB) * 1024 * 1024,
+		MaxFileAge:  100 * 365 * 24 * time.Hour,
+		Compression: *compression,
+	})
+	if err != nil {
+		log.Fatalf("Error creating output directory: %v", err)
+	}
+
+	exported, err := ExportEvents(db, *table, filter, fileClient, *batchSize)
+	if closeErr := fileClient.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Fatalf("Error exporting events after %d events: %v", exported, err)
+	}
+
+	fmt.Printf("Exported %d events to %s\n", exported, *dirPath)
+	os.Exit(0)
+}
+
+// parseExportTime parses an RFC 3339 time or a date, returning the zero
+// time for an empty string
+func parseExportTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// exportQuery builds the SELECT statement and arguments for the events
+// matching the filter, in insertion order. Events stored before the receive
+// time was recorded are filtered by their creation time.
+func exportQuery(table string, filter ExportFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if !filter.From.IsZero() {
+		add("COALESCE(received_at, created_at) >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("COALESCE(received_at, created_at) < $%d", filter.To)
+	}
+	if len(filter.Types) == 1 {
+		add("event_type = $%d", filter.Types[0])
+	} else if len(filter.Types) > 1 {
+		add("event_type = ANY($%d)", pq.Array(filter.Types))
+	}
+	if filter.MatchGUID != "" {
+		add("event_data::jsonb->>'MATCH_GUID' = $%d", filter.MatchGUID)
+	}
+	if filter.Server != "" {
+		add("server = $%d", filter.Server)
+	}
+
+	query := fmt.Sprintf("SELECT event_type, event_data, event_id, server, COALESCE(received_at, created_at), sequence FROM %s", table)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	return query + " ORDER BY id", args
+}
+
+// ExportEvents streams the events matching the filter from the table into
+// dbClient in batches and returns the number of events exported
+func ExportEvents(db *sql.DB, table string, filter ExportFilter, dbClient DBClient, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	query, args := exportQuery(table, filter)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	exported := 0
+	batch := make([]Event, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := dbClient.StoreEvents(batch); err != nil {
+			return fmt.Errorf("failed to write events: %w", err)
+		}
+		exported += len(batch)
+		batch = batch[:0] // Clear batch but keep capacity
+		return nil
+	}
+
+	for rows.Next() {
+		var (
+			event      Event
+			data       string
+			id, server sql.NullString
+			receivedAt sql.NullTime
+			sequence   sql.NullInt64
+		)
+		if err := rows.Scan(&event.Type, &data, &id, &server, &receivedAt, &sequence); err != nil {
+			return exported, fmt.Errorf("failed to read event: %w", err)
+		}
+		event.Data = json.RawMessage(data)
+		event.ID = id.String
+		event.Server = server.String
+		event.ReceivedAt = receivedAt.Time
+		event.Sequence = uint64(sequence.Int64)
+
+		batch = append(batch, event)
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return exported, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return exported, fmt.Errorf("failed to read events: %w", err)
+	}
+
+	return exported, flush()
+}
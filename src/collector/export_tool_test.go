@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExportQuery(t *testing.T) {
+	query, args := exportQuery("events", ExportFilter{})
+	want := "SELECT event_type, event_data, event_id, server, COALESCE(received_at, created_at), sequence FROM events ORDER BY id"
+	if query != want || len(args) != 0 {
+		t.Errorf("Unexpected query without filters:\n got: %s %v\nwant: %s", query, args, want)
+	}
+
+	from := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	query, args = exportQuery("events", ExportFilter{
+		From:      from,
+		Types:     []string{"PLAYER_KILL", "PLAYER_DEATH"},
+		MatchGUID: "abc",
+		Server:    "ca",
+	})
+	want = "SELECT event_type, event_data, event_id, server, COALESCE(received_at, created_at), sequence FROM events" +
+		" WHERE COALESCE(received_at, created_at) >= $1 AND event_type = ANY($2)" +
+		" AND event_data::jsonb->>'MATCH_GUID' = $3 AND server = $4 ORDER BY id"
+	if query != want {
+		t.Errorf("Unexpected query:\n got: %s\nwant: %s", query, want)
+	}
+	if len(args) != 4 || args[0] != from || args[2] != "abc" || args[3] != "ca" {
+		t.Errorf("Unexpected args: %v", args)
+	}
+
+	query, _ = exportQuery("events", ExportFilter{Types: []string{"MATCH_REPORT"}})
+	if want := "SELECT event_type, event_data, event_id, server, COALESCE(received_at, created_at), sequence FROM events" +
+		" WHERE event_type = $1 ORDER BY id"; query != want {
+		t.Errorf("Unexpected query for one type:\n got: %s\nwant: %s", query, want)
+	}
+}
+
+func TestParseExportTime(t *testing.T) {
+	if got, err := parseExportTime("2025-04-20"); err != nil || !got.Equal(time.Date(2025, 4, 20, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected date: %v %v", got, err)
+	}
+	if got, err := parseExportTime("2025-04-20T21:00:00+02:00"); err != nil || got.UTC().Hour() != 19 {
+		t.Errorf("Unexpected time: %v %v", got, err)
+	}
+	if got, err := parseExportTime(""); err != nil || !got.IsZero() {
+		t.Errorf("Expected the zero time for an empty value, got %v %v", got, err)
+	}
+	if _, err := parseExportTime("yesterday"); err == nil {
+		t.Error("Expected an error for an invalid time")
+	}
+}
@@ -1,19 +1,33 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
+// Compression of rotated backup files
+const (
+	FileCompressionNone = "none" // Keep plain JSONL files
+	FileCompressionGzip = "gzip" // Compress files to .jsonl.gz once they are rotated
+)
+
 // FileBackupClient implements the DBClient interface for storing events to files
 type FileBackupClient struct {
 	basePath     string
 	currentFile  *os.File
+	currentPath  string
 	mu           sync.Mutex
 	enabled      bool
 	currentBatch int
@@ -21,6 +35,57 @@ type FileBackupClient struct {
 	maxFileAge   time.Duration
 	fileCreated  time.Time
 	fileSize     int64
+	fileBase     string // Name of the current file without its counter
+	fileIndex    int    // Counter of files created within the second of fileBase
+	compression  string
+	retention    RetentionPolicy
+	// Compression and retention run in the background after a rotation,
+	// one at a time
+	maintenanceMu sync.Mutex
+	maintenance   sync.WaitGroup
+	// Metrics
+	filesCompressed int
+	filesDeleted    int
+	bytesDeleted    int64
+}
+
+// backupRecord is the JSON line format written to backup files
+type backupRecord struct {
+	Timestamp time.Time       `json:"timestamp"`
+	ID        string          `json:"id,omitempty"`
+	Server    string          `json:"server,omitempty"`
+	Sequence  uint64          `json:"sequence,omitempty"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// newBackupRecord converts an event to a backup record, using the receive
+// time as timestamp when the event has one
+func newBackupRecord(event Event) backupRecord {
+	timestamp := event.ReceivedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	return backupRecord{
+		Timestamp: timestamp,
+		ID:        event.ID,
+		Server:    event.Server,
+		Sequence:  event.Sequence,
+		Type:      event.Type,
+		Data:      event.Data,
+	}
+}
+
+// Event converts a backup record back to an event
+func (r backupRecord) Event() Event {
+	return Event{
+		Type:       r.Type,
+		Data:       r.Data,
+		ID:         r.ID,
+		Server:     r.Server,
+		ReceivedAt: r.Timestamp,
+		Sequence:   r.Sequence,
+	}
 }
 
 // FileBackupConfig contains configuration for file backup
@@ -29,6 +94,21 @@ type FileBackupConfig struct {
 	BasePath    string
 	MaxFileSize int64        // Maximum file size in bytes before rotation
 	MaxFileAge  time.Duration // Maximum file age before rotation
+	Compression string        // none or gzip
+	Retention   RetentionPolicy
+}
+
+// RetentionPolicy limits the backup files kept on disk. The oldest files are
+// deleted first; zero values disable a limit.
+type RetentionPolicy struct {
+	MaxAge   time.Duration // Delete files last written longer ago than this
+	MaxFiles int           // Keep at most this many files
+	MaxBytes int64         // Keep at most this many bytes of files
+}
+
+// enabled reports whether any retention limit is set
+func (r RetentionPolicy) enabled() bool {
+	return r.MaxAge > 0 || r.MaxFiles > 0 || r.MaxBytes > 0
 }
 
 // NewFileBackupClient creates a new client for storing events to files
@@ -47,6 +127,12 @@ func NewFileBackupClient(config FileBackupConfig) (DBClient, error) {
 	if config.BasePath == "" {
 		config.BasePath = "events"
 	}
+	if config.Compression == "" {
+		config.Compression = FileCompressionNone
+	}
+	if err := validateFileCompression(config.Compression); err != nil {
+		return nil, err
+	}
 
 	// Ensure base directory exists
 	if err := os.MkdirAll(config.BasePath, 0755); err != nil {
@@ -58,9 +144,14 @@ func NewFileBackupClient(config FileBackupConfig) (DBClient, error) {
 		enabled:     true,
 		maxFileSize: config.MaxFileSize,
 		maxFileAge:  config.MaxFileAge,
+		compression: config.Compression,
+		retention:   config.Retention,
 	}
 
-	log.Printf("File backup initialized. Base path: %s", config.BasePath)
+	// Compress files left over from a previous run and apply retention
+	client.maintainInBackground()
+
+	log.Printf("File backup initialized. Base path: %s (compression: %s)", config.BasePath, config.Compression)
 	return client, nil
 }
 
@@ -87,16 +178,8 @@ func (f *FileBackupClient) StoreEvents(events []Event) error {
 
 	// Serialize events as JSON lines
 	for _, event := range events {
-		// Create a record with timestamp
-		record := struct {
-			Timestamp time.Time `json:"timestamp"`
-			Type      string    `json:"type"`
-			Data      json.RawMessage `json:"data"`
-		}{
-			Timestamp: time.Now(),
-			Type:      event.Type,
-			Data:      event.Data,
-		}
+		// Create a record with the event envelope
+		record := newBackupRecord(event)
 
 		data, err := json.Marshal(record)
 		if err != nil {
@@ -145,97 +228,444 @@ func (f *FileBackupClient) shouldRotateFile() bool {
 // rotateFile closes the current file and opens a new one
 func (f *FileBackupClient) rotateFile() error {
 	// Close current file if open
+	rotated := f.currentFile != nil
 	if f.currentFile != nil {
 		if err := f.currentFile.Close(); err != nil {
 			log.Printf("Warning: Failed to close file: %v", err)
 		}
 		f.currentFile = nil
+		f.currentPath = ""
 	}
 
-	// Create a new file with timestamp in the name
-	timestamp := time.Now().Format("20060102_150405")
-	filename := filepath.Join(f.basePath, fmt.Sprintf("events_%s.jsonl", timestamp))
-	
-	file, err := os.Create(filename)
+	// Create a new file with timestamp in the name, adding a counter when a
+	// file was already rotated within the same second. The counter only goes
+	// up, so a name freed by retention is not reused for a newer file.
+	base := "events_" + time.Now().Format("20060102_150405")
+	if base != f.fileBase {
+		f.fileBase = base
+		f.fileIndex = 0
+	} else {
+		f.fileIndex++
+	}
+	filename := backupFileName(f.basePath, base, f.fileIndex)
+	for fileExists(filename) || fileExists(filename+".gz") {
+		f.fileIndex++
+		filename = backupFileName(f.basePath, base, f.fileIndex)
+	}
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to create file %s: %w", filename, err)
 	}
 
 	f.currentFile = file
+	f.currentPath = filename
 	f.fileCreated = time.Now()
 	f.fileSize = 0
 	f.currentBatch++
 
 	log.Printf("Created new backup file: %s", filename)
+
+	if rotated {
+		f.maintainInBackground()
+	}
 	return nil
 }
 
+// validateFileCompression checks that compression is a known file compression
+func validateFileCompression(compression string) error {
+	switch compression {
+	case FileCompressionNone, FileCompressionGzip:
+		return nil
+	}
+	return fmt.Errorf("unknown file backup compression %q, use %s or %s", compression, FileCompressionNone, FileCompressionGzip)
+}
+
+// backupFileName returns the path of a backup file created within the
+// second of base, with a counter for all but the first one
+func backupFileName(dir, base string, index int) string {
+	if index == 0 {
+		return filepath.Join(dir, base+".jsonl")
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s_%d.jsonl", base, index))
+}
+
+// backupFileOrder splits a backup file name into its creation time and
+// counter
+func backupFileOrder(file string) (string, int) {
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(file), ".gz"), ".jsonl")
+	if strings.Count(name, "_") == 3 {
+		sep := strings.LastIndex(name, "_")
+		if index, err := strconv.Atoi(name[sep+1:]); err == nil {
+			return name[:sep], index
+		}
+	}
+	return name, 0
+}
+
+// fileExists reports whether a file exists at path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// backupFiles returns the plain and compressed backup files in dir, oldest
+// first
+func backupFiles(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "events_*.jsonl*"))
+	if err != nil {
+		return nil, err
+	}
+
+	files := matches[:0]
+	for _, file := range matches {
+		if strings.HasSuffix(file, ".jsonl") || strings.HasSuffix(file, ".jsonl.gz") {
+			files = append(files, file)
+		}
+	}
+	// The file names start with their creation time (events_YYYYMMDD_HHMMSS),
+	// followed by a counter for files created within the same second
+	sort.Slice(files, func(i, j int) bool {
+		baseI, indexI := backupFileOrder(files[i])
+		baseJ, indexJ := backupFileOrder(files[j])
+		if baseI != baseJ {
+			return baseI < baseJ
+		}
+		return indexI < indexJ
+	})
+	return files, nil
+}
+
+// maintainInBackground compresses rotated files and applies the retention
+// policy without holding up writes
+func (f *FileBackupClient) maintainInBackground() {
+	if f.compression == FileCompressionNone && !f.retention.enabled() {
+		return
+	}
+	f.maintenance.Add(1)
+	go func() {
+		defer f.maintenance.Done()
+		f.maintain()
+	}()
+}
+
+// maintain compresses every plain backup file except the one being written,
+// then deletes the oldest files beyond the retention limits
+func (f *FileBackupClient) maintain() {
+	f.maintenanceMu.Lock()
+	defer f.maintenanceMu.Unlock()
+
+	if f.compression == FileCompressionGzip {
+		f.mu.Lock()
+		files, err := backupFiles(f.basePath)
+		current := f.currentPath
+		f.mu.Unlock()
+		if err != nil {
+			log.Printf("Error listing backup files: %v", err)
+			return
+		}
+
+		for _, file := range files {
+			if file == current || !strings.HasSuffix(file, ".jsonl") {
+				continue
+			}
+			if err := compressFile(file); err != nil {
+				log.Printf("Error compressing backup file %s: %v", file, err)
+				continue
+			}
+			f.mu.Lock()
+			f.filesCompressed++
+			f.mu.Unlock()
+		}
+	}
+
+	if f.retention.enabled() {
+		f.applyRetention()
+	}
+}
+
+// compressFile replaces a plain backup file with a gzipped copy. The copy is
+// renamed into place before the plain file is removed, so a crash leaves at
+// least one complete file.
+func compressFile(path string) error {
+	compressed := path + ".gz"
+	if fileExists(compressed) {
+		// A previous run was interrupted after the rename
+		return os.Remove(path)
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := compressed + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, compressed); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
+
+// applyRetention deletes the oldest backup files until the remaining ones are
+// within the retention limits. The file being written is counted but never
+// deleted.
+func (f *FileBackupClient) applyRetention() {
+	f.mu.Lock()
+	files, err := backupFiles(f.basePath)
+	current := f.currentPath
+	f.mu.Unlock()
+	if err != nil {
+		log.Printf("Error listing backup files: %v", err)
+		return
+	}
+
+	infos := make([]os.FileInfo, 0, len(files))
+	paths := make([]string, 0, len(files))
+	var totalBytes int64
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, info)
+		paths = append(paths, file)
+		totalBytes += info.Size()
+	}
+
+	count := len(paths)
+	now := time.Now()
+	for i, file := range paths {
+		if file == current {
+			continue
+		}
+		expired := f.retention.MaxAge > 0 && now.Sub(infos[i].ModTime()) > f.retention.MaxAge
+		tooMany := f.retention.MaxFiles > 0 && count > f.retention.MaxFiles
+		tooLarge := f.retention.MaxBytes > 0 && totalBytes > f.retention.MaxBytes
+		if !expired && !tooMany && !tooLarge {
+			continue
+		}
+
+		if err := os.Remove(file); err != nil {
+			log.Printf("Error deleting backup file %s: %v", file, err)
+			continue
+		}
+		log.Printf("Deleted backup file %s (retention)", file)
+		count--
+		totalBytes -= infos[i].Size()
+
+		f.mu.Lock()
+		f.filesDeleted++
+		f.bytesDeleted += infos[i].Size()
+		f.mu.Unlock()
+	}
+}
+
+// gzipFileReader reads a gzipped file and closes both the gzip reader and
+// the file
+type gzipFileReader struct {
+	*gzip.Reader
+	file io.Closer
+}
+
+func (g *gzipFileReader) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// openBackupFile opens a backup file for reading, decompressing it when it
+// is gzipped
+func openBackupFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return newBackupReader(file, file)
+}
+
+// newBackupReader returns a reader of the uncompressed contents of a backup
+// file read from r, which closer closes
+func newBackupReader(r io.Reader, closer io.Closer) (io.ReadCloser, error) {
+	// Detect gzip by its magic number rather than the file name
+	reader := bufio.NewReader(r)
+	magic, _ := reader.Peek(2)
+	if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return struct {
+			io.Reader
+			io.Closer
+		}{reader, closer}, nil
+	}
+
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		closer.Close()
+		return nil, fmt.Errorf("failed to read gzip header: %w", err)
+	}
+	return &gzipFileReader{Reader: gz, file: closer}, nil
+}
+
+// ImportResult summarizes the import of a backup file
+type ImportResult struct {
+	Events   int   // Events stored
+	Skipped  int   // Lines that could not be decoded
+	Offset   int64 // Uncompressed bytes committed to storage
+	Complete bool  // The whole file was committed
+}
+
 // ImportEventsFromFile imports events from a specified backup file
 func ImportEventsFromFile(filePath string, dbClient DBClient, batchSize int) error {
-	file, err := os.Open(filePath)
+	_, err := ImportEventsFromOffset(filePath, dbClient, batchSize, 0, nil)
+	return err
+}
+
+// ImportEventsFromOffset imports the events of a backup file that follow the
+// given uncompressed byte offset. commit is called with the progress after
+// every stored batch. The import stops at the first batch that fails to
+// store, so the committed offset never skips events.
+func ImportEventsFromOffset(filePath string, dbClient DBClient, batchSize int, offset int64, commit func(ImportResult) error) (ImportResult, error) {
+	result := ImportResult{Offset: offset}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	file, err := openBackupFile(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open file %s: %w", filePath, err)
+		return result, fmt.Errorf("failed to open file %s: %w", filePath, err)
 	}
 	defer file.Close()
 
-	decoder := json.NewDecoder(file)
-	
 	batch := make([]Event, 0, batchSize)
-	recordCount := 0
-	
-	// Process one JSON object at a time
-	for decoder.More() {
-		var record struct {
-			Timestamp time.Time `json:"timestamp"`
-			Type      string    `json:"type"`
-			Data      json.RawMessage `json:"data"`
+	position := offset
+
+	// store writes the batch and commits the offset of the lines read so far
+	store := func() error {
+		if len(batch) > 0 {
+			if err := dbClient.StoreEvents(batch); err != nil {
+				return fmt.Errorf("failed to store batch of %d events after offset %d of %s: %w",
+					len(batch), result.Offset, filePath, err)
+			}
+			result.Events += len(batch)
+			batch = batch[:0] // Clear batch but keep capacity
 		}
-		
-		if err := decoder.Decode(&record); err != nil {
-			log.Printf("Error decoding record: %v", err)
-			continue
+		result.Offset = position
+		if commit != nil {
+			return commit(result)
 		}
-		
-		// Convert to Event format
-		event := Event{
-			Type: record.Type,
-			Data: record.Data,
+		return nil
+	}
+
+	err = scanBackupRecords(file, filePath, offset, func(event *Event, end int64) error {
+		position = end
+		if event == nil {
+			result.Skipped++
+			return nil
 		}
-		
-		batch = append(batch, event)
-		recordCount++
-		
+
 		// Process in batches
+		batch = append(batch, *event)
 		if len(batch) >= batchSize {
-			if err := dbClient.StoreEvents(batch); err != nil {
-				log.Printf("Error storing events from file: %v", err)
-			}
-			batch = batch[:0] // Clear batch but keep capacity
+			return store()
 		}
+		return nil
+	})
+	if err != nil {
+		return result, err
 	}
-	
+
 	// Process any remaining events
-	if len(batch) > 0 {
-		if err := dbClient.StoreEvents(batch); err != nil {
-			log.Printf("Error storing final batch of events from file: %v", err)
+	result.Complete = true
+	if err := store(); err != nil {
+		result.Complete = false
+		return result, err
+	}
+
+	log.Printf("Imported %d events from %s", result.Events, filePath)
+	return result, nil
+}
+
+// scanBackupRecords reads the JSON lines of an uncompressed backup stream
+// that follow the given byte offset, calling fn with the event of every line
+// and the offset following it. Lines that cannot be decoded are logged and
+// passed as a nil event. Scanning stops at the first error fn returns.
+func scanBackupRecords(r io.Reader, name string, offset int64, fn func(event *Event, end int64) error) error {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	if offset > 0 {
+		if _, err := io.CopyN(io.Discard, reader, offset); err != nil {
+			return fmt.Errorf("failed to skip to offset %d of %s: %w", offset, name, err)
+		}
+	}
+
+	position := offset
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		lineOffset := position
+		position += int64(len(line))
+
+		// Process one JSON line at a time
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			var record backupRecord
+			var event *Event
+			if err := json.Unmarshal(trimmed, &record); err != nil {
+				log.Printf("Error decoding record at offset %d of %s: %v", lineOffset, name, err)
+			} else {
+				e := record.Event()
+				event = &e
+			}
+			if err := fn(event, position); err != nil {
+				return err
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read %s: %w", name, readErr)
 		}
 	}
-	
-	log.Printf("Imported %d events from %s", recordCount, filePath)
-	return nil
 }
 
-// Close closes the current file
+// Close closes the current file, compressing it and applying retention
+// before it returns
 func (f *FileBackupClient) Close() error {
 	f.mu.Lock()
-	defer f.mu.Unlock()
-
+	var err error
 	if f.currentFile != nil {
-		err := f.currentFile.Close()
+		err = f.currentFile.Close()
 		f.currentFile = nil
-		return err
+		f.currentPath = ""
 	}
-	return nil
+	f.mu.Unlock()
+
+	f.maintainInBackground()
+	f.maintenance.Wait()
+	return err
 }
 
 // GetMetrics returns metrics about the file backup client
@@ -250,6 +680,10 @@ func (f *FileBackupClient) GetMetrics() map[string]interface{} {
 		"current_batch":      f.currentBatch,
 		"max_file_size":      f.maxFileSize,
 		"max_file_age_hours": f.maxFileAge.Hours(),
+		"compression":        f.compression,
+		"files_compressed":   f.filesCompressed,
+		"files_deleted":      f.filesDeleted,
+		"bytes_deleted":      f.bytesDeleted,
 	}
 
 	if f.currentFile != nil {
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileBackupEnvelopeRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	client, err := NewFileBackupClient(FileBackupConfig{Enabled: true, BasePath: dir})
+	if err != nil {
+		t.Fatalf("Failed to create file backup client: %v", err)
+	}
+
+	receivedAt := time.Date(2025, 4, 20, 21, 0, 0, 0, time.UTC)
+	event := Event{
+		Type:       "PLAYER_KILL",
+		Data:       json.RawMessage(`{"MATCH_GUID":"abc"}`),
+		ID:         newEventID(),
+		Server:     "ca",
+		ReceivedAt: receivedAt,
+		Sequence:   42,
+	}
+
+	if err := client.StoreEvents([]Event{event}); err != nil {
+		t.Fatalf("Failed to store events: %v", err)
+	}
+	client.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "events_*.jsonl"))
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 backup file, got %d", len(files))
+	}
+
+	// Import into a mock client and compare the envelope
+	var imported []Event
+	mockClient := &mockDBClient{
+		storeEventsFunc: func(events []Event) error {
+			imported = append(imported, events...)
+			return nil
+		},
+	}
+	if err := ImportEventsFromFile(files[0], mockClient, 10); err != nil {
+		t.Fatalf("Failed to import events: %v", err)
+	}
+
+	if len(imported) != 1 {
+		t.Fatalf("Expected 1 imported event, got %d", len(imported))
+	}
+
+	got := imported[0]
+	if got.ID != event.ID || got.Server != event.Server || got.Sequence != event.Sequence {
+		t.Errorf("Envelope not preserved: %+v", got)
+	}
+	if !got.ReceivedAt.Equal(receivedAt) {
+		t.Errorf("Expected receive time %v, got %v", receivedAt, got.ReceivedAt)
+	}
+}
+
+func TestFileBackupCompressesRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	// Rotate before every batch
+	client, err := NewFileBackupClient(FileBackupConfig{
+		Enabled:     true,
+		BasePath:    dir,
+		MaxFileSize: 1,
+		Compression: FileCompressionGzip,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create file backup client: %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err := client.StoreEvents([]Event{sequencedEvent(i)}); err != nil {
+			t.Fatalf("Failed to store events: %v", err)
+		}
+	}
+	client.Close()
+
+	files, err := backupFiles(dir)
+	if err != nil || len(files) != 3 {
+		t.Fatalf("Expected 3 backup files, got %v (%v)", files, err)
+	}
+
+	var imported []Event
+	mockClient := &mockDBClient{
+		storeEventsFunc: func(events []Event) error {
+			imported = append(imported, events...)
+			return nil
+		},
+	}
+	for _, file := range files {
+		if filepath.Ext(file) != ".gz" {
+			t.Errorf("Expected %s to be compressed", file)
+		}
+		if err := ImportEventsFromFile(file, mockClient, 10); err != nil {
+			t.Fatalf("Failed to import events: %v", err)
+		}
+	}
+
+	if len(imported) != 3 {
+		t.Fatalf("Expected 3 imported events, got %d", len(imported))
+	}
+	for i, event := range imported {
+		if event.Sequence != uint64(i+1) {
+			t.Errorf("Expected events in file order, got %d at position %d", event.Sequence, i)
+		}
+	}
+}
+
+func TestFileBackupRetention(t *testing.T) {
+	dir := t.TempDir()
+
+	// A file from a previous run that is older than the retention age
+	old := filepath.Join(dir, "events_20240101_000000.jsonl")
+	if err := os.WriteFile(old, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	oldTime := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(old, oldTime, oldTime)
+
+	client, err := NewFileBackupClient(FileBackupConfig{
+		Enabled:     true,
+		BasePath:    dir,
+		MaxFileSize: 1,
+		Retention:   RetentionPolicy{MaxAge: 24 * time.Hour, MaxFiles: 2},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create file backup client: %v", err)
+	}
+	for i := 1; i <= 4; i++ {
+		client.StoreEvents([]Event{sequencedEvent(i)})
+	}
+	client.Close()
+
+	files, _ := backupFiles(dir)
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files to be kept, got %v", files)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("Expected the expired file to be deleted")
+	}
+
+	// The newest files are kept
+	var imported []Event
+	mockClient := &mockDBClient{
+		storeEventsFunc: func(events []Event) error {
+			imported = append(imported, events...)
+			return nil
+		},
+	}
+	for _, file := range files {
+		ImportEventsFromFile(file, mockClient, 10)
+	}
+	if len(imported) != 2 || imported[0].Sequence != 3 || imported[1].Sequence != 4 {
+		t.Errorf("Expected the last 2 events to be kept, got %+v", imported)
+	}
+	if deleted := client.GetMetrics()["files_deleted"]; deleted != 3 {
+		t.Errorf("Expected 3 deleted files, got %v", deleted)
+	}
+}
+
+func TestFileBackupRetentionMaxBytes(t *testing.T) {
+	dir := t.TempDir()
+	client, err := NewFileBackupClient(FileBackupConfig{
+		Enabled:     true,
+		BasePath:    dir,
+		MaxFileSize: 1,
+		Retention:   RetentionPolicy{MaxBytes: 1},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create file backup client: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		client.StoreEvents([]Event{sequencedEvent(i)})
+	}
+
+	// The file being written is never deleted
+	client.(*FileBackupClient).maintain()
+	files, _ := backupFiles(dir)
+	if len(files) != 1 {
+		t.Errorf("Expected only the current file to be kept, got %v", files)
+	}
+	client.Close()
+}
+
+func TestBackupFilesOrder(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{
+		"events_20250420_200001.jsonl",
+		"events_20250420_200000_10.jsonl.gz",
+		"events_20250420_200000_2.jsonl",
+		"events_20250420_200000.jsonl.gz",
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	files, err := backupFiles(dir)
+	if err != nil {
+		t.Fatalf("Failed to list backup files: %v", err)
+	}
+	want := []string{names[3], names[2], names[1], names[0]}
+	for i := range want {
+		if i >= len(files) || filepath.Base(files[i]) != want[i] {
+			t.Fatalf("Expected files in order %v, got %v", want, files)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"quake-stats/qlstats"
+)
+
+// FilterRule drops events matching all of its conditions. Conditions that
+// are not set are ignored.
+type FilterRule struct {
+	Name       string   `mapstructure:"name"`
+	Types      []string `mapstructure:"types"`       // Drop events of these types
+	AllowTypes []string `mapstructure:"allow_types"` // Drop events of any type not listed
+	Warmup     bool     `mapstructure:"warmup"`      // Drop events sent during warmup
+	Bots       bool     `mapstructure:"bots"`        // Drop kills and deaths with a bot killer or victim
+	MatchGUIDs []string `mapstructure:"match_guids"` // Drop events of these matches
+	Servers    []string `mapstructure:"servers"`     // Drop events from these servers
+}
+
+// compiledRule is a filter rule prepared for fast matching
+type compiledRule struct {
+	name       string
+	types      map[string]bool
+	allowTypes map[string]bool
+	warmup     bool
+	bots       bool
+	matchGUIDs map[string]bool
+	servers    map[string]bool
+}
+
+// needsData reports whether the rule has to look inside the event DATA
+func (r *compiledRule) needsData() bool {
+	return r.warmup || r.bots || r.matchGUIDs != nil
+}
+
+// filterFields are the DATA fields filter rules can match on
+type filterFields struct {
+	MatchGUID string       `json:"MATCH_GUID"`
+	Warmup    qlstats.Bool `json:"WARMUP"`
+	Killer    *struct {
+		Bot qlstats.Bool `json:"BOT"`
+	} `json:"KILLER"`
+	Victim *struct {
+		Bot qlstats.Bool `json:"BOT"`
+	} `json:"VICTIM"`
+}
+
+// matches reports whether the event matches every condition of the rule
+func (r *compiledRule) matches(e Event, fields *filterFields) bool {
+	if r.types != nil && !r.types[e.Type] {
+		return false
+	}
+	if r.allowTypes != nil && r.allowTypes[e.Type] {
+		return false
+	}
+	if r.servers != nil && !r.servers[e.Server] {
+		return false
+	}
+	if !r.needsData() {
+		return true
+	}
+	if fields == nil {
+		return false
+	}
+	if r.warmup && !bool(fields.Warmup) {
+		return false
+	}
+	if r.bots {
+		killerBot := fields.Killer != nil && bool(fields.Killer.Bot)
+		victimBot := fields.Victim != nil && bool(fields.Victim.Bot)
+		if !killerBot && !victimBot {
+			return false
+		}
+	}
+	if r.matchGUIDs != nil && !r.matchGUIDs[fields.MatchGUID] {
+		return false
+	}
+	return true
+}
+
+// EventFilter applies filter rules to events and counts drops per rule
+type EventFilter struct {
+	rules     []*compiledRule
+	needsData bool
+	mu        sync.Mutex
+	dropped   map[string]int64
+}
+
+// NewEventFilter compiles the configured rules. Rules without any
+// condition are skipped since they would drop every event.
+func NewEventFilter(rules []FilterRule) *EventFilter {
+	filter := &EventFilter{dropped: make(map[string]int64)}
+
+	for i, rule := range rules {
+		compiled := &compiledRule{
+			name:       rule.Name,
+			types:      stringSet(rule.Types),
+			allowTypes: stringSet(rule.AllowTypes),
+			warmup:     rule.Warmup,
+			bots:       rule.Bots,
+			matchGUIDs: stringSet(rule.MatchGUIDs),
+			servers:    stringSet(rule.Servers),
+		}
+		if compiled.name == "" {
+			compiled.name = fmt.Sprintf("rule_%d", i)
+		}
+
+		if compiled.types == nil && compiled.allowTypes == nil && compiled.servers == nil && !compiled.needsData() {
+			log.Printf("Warning: Ignoring filter rule %s without conditions", compiled.name)
+			continue
+		}
+
+		filter.rules = append(filter.rules, compiled)
+		filter.dropped[compiled.name] = 0
+		if compiled.needsData() {
+			filter.needsData = true
+		}
+	}
+
+	return filter
+}
+
+// Len returns the number of active rules
+func (f *EventFilter) Len() int {
+	return len(f.rules)
+}
+
+// Drop reports whether the event should be dropped and counts the drop
+// against the first matching rule
+func (f *EventFilter) Drop(e Event) bool {
+	if len(f.rules) == 0 {
+		return false
+	}
+
+	var fields *filterFields
+	if f.needsData {
+		fields = &filterFields{}
+		if err := json.Unmarshal(e.Data, fields); err != nil {
+			// Let events we cannot inspect through, validation handles them
+			fields = nil
+		}
+	}
+
+	for _, rule := range f.rules {
+		if rule.matches(e, fields) {
+			f.mu.Lock()
+			f.dropped[rule.name]++
+			f.mu.Unlock()
+			return true
+		}
+	}
+	return false
+}
+
+// Dropped returns the number of events dropped by each rule
+func (f *EventFilter) Dropped() map[string]int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dropped := make(map[string]int64, len(f.dropped))
+	for name, count := range f.dropped {
+		dropped[name] = count
+	}
+	return dropped
+}
+
+// Summary returns the drop counts as "rule=count" pairs sorted by rule name
+func (f *EventFilter) Summary() string {
+	dropped := f.Dropped()
+	names := make([]string, 0, len(dropped))
+	for name := range dropped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, dropped[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// stringSet converts a list to a set, returning nil for an empty list
+func stringSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
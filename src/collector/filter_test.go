@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEventFilterRules(t *testing.T) {
+	filter := NewEventFilter([]FilterRule{
+		{Name: "warmup", Warmup: true},
+		{Name: "bots", Bots: true},
+		{Name: "medals", Types: []string{"PLAYER_MEDAL"}},
+		{Name: "match", MatchGUIDs: []string{"bad-match"}},
+		{Name: "duel-stats", Servers: []string{"duel"}, Types: []string{"PLAYER_STATS"}},
+		{Name: "empty"},
+	})
+
+	if filter.Len() != 5 {
+		t.Fatalf("Expected 5 active rules, got %d", filter.Len())
+	}
+
+	testCases := []struct {
+		name    string
+		event   Event
+		dropped bool
+	}{
+		{"warmup kill", Event{Type: "PLAYER_KILL", Data: json.RawMessage(`{"WARMUP":true}`)}, true},
+		{"bot killer", Event{Type: "PLAYER_KILL", Data: json.RawMessage(`{"WARMUP":false,"KILLER":{"BOT":true},"VICTIM":{"BOT":false}}`)}, true},
+		{"bot victim", Event{Type: "PLAYER_DEATH", Data: json.RawMessage(`{"KILLER":null,"VICTIM":{"BOT":1}}`)}, true},
+		{"human kill", Event{Type: "PLAYER_KILL", Data: json.RawMessage(`{"WARMUP":false,"KILLER":{"BOT":false},"VICTIM":{"BOT":false}}`)}, false},
+		{"medal", Event{Type: "PLAYER_MEDAL", Data: json.RawMessage(`{"WARMUP":false}`)}, true},
+		{"bad match", Event{Type: "ROUND_OVER", Data: json.RawMessage(`{"MATCH_GUID":"bad-match"}`)}, true},
+		{"good match", Event{Type: "ROUND_OVER", Data: json.RawMessage(`{"MATCH_GUID":"good-match"}`)}, false},
+		{"duel stats", Event{Type: "PLAYER_STATS", Server: "duel", Data: json.RawMessage(`{}`)}, true},
+		{"ca stats", Event{Type: "PLAYER_STATS", Server: "ca", Data: json.RawMessage(`{}`)}, false},
+	}
+
+	for _, tc := range testCases {
+		if got := filter.Drop(tc.event); got != tc.dropped {
+			t.Errorf("%s: expected dropped=%v, got %v", tc.name, tc.dropped, got)
+		}
+	}
+
+	dropped := filter.Dropped()
+	expected := map[string]int64{"warmup": 1, "bots": 2, "medals": 1, "match": 1, "duel-stats": 1}
+	for name, count := range expected {
+		if dropped[name] != count {
+			t.Errorf("Expected rule %s to drop %d events, got %d", name, count, dropped[name])
+		}
+	}
+}
+
+func TestEventFilterAllowTypes(t *testing.T) {
+	filter := NewEventFilter([]FilterRule{
+		{Name: "allow", AllowTypes: []string{"MATCH_STARTED", "MATCH_REPORT"}},
+	})
+
+	if filter.Drop(Event{Type: "MATCH_REPORT"}) {
+		t.Error("Expected allowed type to pass")
+	}
+	if !filter.Drop(Event{Type: "PLAYER_KILL"}) {
+		t.Error("Expected type outside the allow list to be dropped")
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ComponentHealth is the readiness of one component of the collector
+type ComponentHealth struct {
+	Healthy bool        `json:"healthy"`
+	Reason  string      `json:"reason,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// HealthReport is the JSON body of the health endpoints
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components,omitempty"`
+}
+
+// HealthCheck reports the readiness of a component
+type HealthCheck func() ComponentHealth
+
+// HealthChecker serves /healthz and /readyz from a set of named checks
+type HealthChecker struct {
+	mu     sync.Mutex
+	checks map[string]HealthCheck
+}
+
+// NewHealthChecker creates a health checker without checks
+func NewHealthChecker() *HealthChecker {
+	return &HealthChecker{checks: make(map[string]HealthCheck)}
+}
+
+// AddCheck registers a readiness check under the given component name
+func (h *HealthChecker) AddCheck(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// Ready runs all checks. The collector is ready when every component is healthy.
+func (h *HealthChecker) Ready() HealthReport {
+	h.mu.Lock()
+	checks := make(map[string]HealthCheck, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mu.Unlock()
+
+	report := HealthReport{Status: "ok", Components: make(map[string]ComponentHealth, len(checks))}
+	for name, check := range checks {
+		component := check()
+		if !component.Healthy {
+			report.Status = "unavailable"
+		}
+		report.Components[name] = component
+	}
+	return report
+}
+
+// ServeLiveness handles /healthz, which only reports that the process is up
+func (h *HealthChecker) ServeLiveness(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, http.StatusOK, HealthReport{Status: "ok"})
+}
+
+// ServeReadiness handles /readyz, answering 503 when a component is unhealthy
+func (h *HealthChecker) ServeReadiness(w http.ResponseWriter, r *http.Request) {
+	report := h.Ready()
+	status := http.StatusOK
+	if report.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	writeHealthReport(w, status, report)
+}
+
+// writeHealthReport writes a health report as JSON
+func writeHealthReport(w http.ResponseWriter, status int, report HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+}
+
+// ServerActivity is the receive state of one server in the ZMQ check
+type ServerActivity struct {
+	LastReceived time.Time `json:"last_received,omitzero"`
+	IdleSeconds  int64     `json:"idle_seconds"`
+}
+
+// zmqActivityCheck fails when any server sent no message for maxIdle. Servers
+// that sent nothing yet count as idle since the check was created.
+func zmqActivityCheck(validator *EventValidator, servers []ServerConfig, maxIdle time.Duration) HealthCheck {
+	started := time.Now()
+	return func() ComponentHealth {
+		now := time.Now()
+		details := make(map[string]ServerActivity, len(servers))
+		var idleServers []string
+		for _, server := range servers {
+			last := validator.LastReceived(server.Name)
+			since := last
+			if since.IsZero() {
+				since = started
+			}
+			idle := now.Sub(since)
+			if idle >= maxIdle {
+				idleServers = append(idleServers, server.Name)
+			}
+			details[server.Name] = ServerActivity{LastReceived: last, IdleSeconds: int64(idle.Seconds())}
+		}
+
+		health := ComponentHealth{Healthy: len(idleServers) == 0, Details: details}
+		if !health.Healthy {
+			health.Reason = fmt.Sprintf("no messages received for %s from %s", maxIdle, strings.Join(idleServers, ", "))
+		}
+		return health
+	}
+}
+
+// SinkHealthReporter is implemented by storage clients that keep track of
+// the outcome of recent writes
+type SinkHealthReporter interface {
+	SinkHealth() []SinkHealth
+}
+
+// SinkHealth is the outcome of the recent writes to a storage sink
+type SinkHealth struct {
+	Name                string    `json:"name"`
+	Healthy             bool      `json:"healthy"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastSuccess         time.Time `json:"last_success,omitzero"`
+	LastFailure         time.Time `json:"last_failure,omitzero"`
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+// sinkTracker records write results for SinkHealth. A sink is healthy until
+// a write fails and again after the next successful write.
+type sinkTracker struct {
+	mu     sync.Mutex
+	health SinkHealth
+}
+
+// newSinkTracker creates a tracker for the named sink
+func newSinkTracker(name string) *sinkTracker {
+	return &sinkTracker{health: SinkHealth{Name: name, Healthy: true}}
+}
+
+// record records the result of a write
+func (t *sinkTracker) record(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.health.Healthy = false
+		t.health.ConsecutiveFailures++
+		t.health.LastFailure = time.Now()
+		t.health.LastError = err.Error()
+		return
+	}
+	t.health.Healthy = true
+	t.health.ConsecutiveFailures = 0
+	t.health.LastSuccess = time.Now()
+}
+
+// snapshot returns the current sink health
+func (t *sinkTracker) snapshot() SinkHealth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.health
+}
+
+// storageCheck fails when every storage sink is failing
+func storageCheck(reporter SinkHealthReporter) HealthCheck {
+	return func() ComponentHealth {
+		sinks := reporter.SinkHealth()
+		healthy := len(sinks) == 0
+		for _, sink := range sinks {
+			if sink.Healthy {
+				healthy = true
+			}
+		}
+
+		health := ComponentHealth{Healthy: healthy, Details: sinks}
+		if !healthy {
+			health.Reason = "all storage sinks are failing"
+		}
+		return health
+	}
+}
+
+// SpoolUsage is the state of the failed batch spool in the spool check
+type SpoolUsage struct {
+	PendingBatches int   `json:"pending_batches"`
+	DroppedBatches int64 `json:"dropped_batches"`
+	DroppedEvents  int64 `json:"dropped_events"`
+}
+
+// spoolCheck fails while the spool is full and rejects failed batches.
+// Batches and events lost to the size limit are reported in the details.
+func spoolCheck(spool *Spool) HealthCheck {
+	return func() ComponentHealth {
+		batches, events := spool.Dropped()
+		health := ComponentHealth{Healthy: true, Details: SpoolUsage{
+			PendingBatches: spool.Pending(),
+			DroppedBatches: batches,
+			DroppedEvents:  events,
+		}}
+		if spool.Full() {
+			health.Healthy = false
+			health.Reason = "spool is full, failed batches are dropped"
+		}
+		return health
+	}
+}
+
+// ChannelUsage is the fill level of the processor channel
+type ChannelUsage struct {
+	Length   int `json:"length"`
+	Capacity int `json:"capacity"`
+}
+
+// channelCheck fails when the processor channel is at least saturation full,
+// a fraction between 0 and 1
+func channelCheck(processor *EventProcessor, saturation float64) HealthCheck {
+	return func() ComponentHealth {
+		length, capacity := processor.ChannelUsage()
+		health := ComponentHealth{Healthy: true, Details: ChannelUsage{Length: length, Capacity: capacity}}
+		if capacity > 0 && float64(length) >= saturation*float64(capacity) {
+			health.Healthy = false
+			health.Reason = fmt.Sprintf("processor channel is saturated (%d/%d)", length, capacity)
+		}
+		return health
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readyz calls the readiness handler and decodes the report
+func readyz(t *testing.T, h *HealthChecker) (int, HealthReport) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	h.ServeReadiness(recorder, httptest.NewRequest("GET", "/readyz", nil))
+
+	var report HealthReport
+	if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode readiness report %s: %v", recorder.Body.String(), err)
+	}
+	return recorder.Code, report
+}
+
+func TestReadinessStorage(t *testing.T) {
+	var postgresErr error
+	postgres := &mockDBClient{storeEventsFunc: func(events []Event) error { return postgresErr }}
+	backupErr := errors.New("disk full")
+	backup := &mockDBClient{storeEventsFunc: func(events []Event) error { return backupErr }}
+	client := NewMultiDBClient([]DBClient{postgres, backup}, MultiClientConfig{})
+	defer client.Close()
+	multi := client.(*MultiDBClient)
+
+	health := NewHealthChecker()
+	health.AddCheck("storage", storageCheck(client.(SinkHealthReporter)))
+
+	events := []Event{{Type: "ROUND_OVER"}}
+
+	// One failing sink is not enough to be unready
+	client.StoreEvents(events)
+	multi.wait()
+	if code, report := readyz(t, health); code != http.StatusOK || report.Status != "ok" {
+		t.Fatalf("Expected ready with one healthy sink, got %d %+v", code, report)
+	}
+
+	postgresErr = errors.New("connection refused")
+	client.StoreEvents(events)
+	multi.wait()
+	code, report := readyz(t, health)
+	if code != http.StatusServiceUnavailable || report.Components["storage"].Healthy {
+		t.Fatalf("Expected unready with all sinks failing, got %d %+v", code, report)
+	}
+
+	sinks := client.(SinkHealthReporter).SinkHealth()
+	if sinks[0].Name != "client_0" || sinks[0].LastError != "connection refused" || sinks[1].ConsecutiveFailures != 2 {
+		t.Errorf("Unexpected sink health: %+v", sinks)
+	}
+
+	// A successful write makes the sink healthy again
+	backupErr = nil
+	client.StoreEvents(events)
+	multi.wait()
+	if code, _ := readyz(t, health); code != http.StatusOK {
+		t.Errorf("Expected ready after recovery, got %d", code)
+	}
+}
+
+func TestReadinessZmqActivity(t *testing.T) {
+	validator := NewEventValidator(&mockEventProcessor{}, nil)
+	servers := []ServerConfig{{Name: "ca"}, {Name: "duel"}}
+
+	health := NewHealthChecker()
+	health.AddCheck("zmq", zmqActivityCheck(validator, servers, 20*time.Millisecond))
+
+	if code, _ := readyz(t, health); code != http.StatusOK {
+		t.Fatalf("Expected ready during the startup grace period, got %d", code)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	code, report := readyz(t, health)
+	if code != http.StatusServiceUnavailable || report.Components["zmq"].Reason == "" {
+		t.Fatalf("Expected unready without messages, got %d %+v", code, report)
+	}
+
+	// A single idle server keeps the collector unready
+	validator.ProcessEvent(Event{Type: "ROUND_OVER", Server: "duel", Data: json.RawMessage(`{"ROUND":1}`)})
+	code, report = readyz(t, health)
+	if code != http.StatusServiceUnavailable || !strings.Contains(report.Components["zmq"].Reason, "ca") {
+		t.Fatalf("Expected unready while ca is idle, got %d %+v", code, report)
+	}
+
+	validator.ProcessEvent(Event{Type: "ROUND_OVER", Server: "ca", Data: json.RawMessage(`{"ROUND":1}`)})
+	if code, _ := readyz(t, health); code != http.StatusOK {
+		t.Errorf("Expected ready once every server sent a message, got %d", code)
+	}
+}
+
+func TestReadinessChannelSaturation(t *testing.T) {
+	processor := NewEventProcessor(Config{BatchSize: 10, FlushIntervalSec: 30}, nil)
+
+	health := NewHealthChecker()
+	health.AddCheck("processor", channelCheck(processor, 0.5))
+
+	// Nothing drains the channel because the processor is not running
+	for i := 0; i < 499; i++ {
+		processor.Submit(Event{Type: "ROUND_OVER"})
+	}
+	if code, _ := readyz(t, health); code != http.StatusOK {
+		t.Fatalf("Expected ready below the threshold, got %d", code)
+	}
+
+	processor.Submit(Event{Type: "ROUND_OVER"})
+	if code, report := readyz(t, health); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected unready at the threshold, got %d %+v", code, report)
+	}
+}
+
+func TestReadinessSpoolFull(t *testing.T) {
+	spool, err := NewSpool(SpoolConfig{Path: t.TempDir(), MaxBytes: 100})
+	if err != nil {
+		t.Fatalf("Failed to create spool: %v", err)
+	}
+
+	health := NewHealthChecker()
+	health.AddCheck("spool", spoolCheck(spool))
+	if code, _ := readyz(t, health); code != http.StatusOK {
+		t.Fatalf("Expected ready with an empty spool, got %d", code)
+	}
+
+	for i := 0; i < 3; i++ {
+		spool.Append(spoolTestEvents("PLAYER_KILL"))
+	}
+	code, report := readyz(t, health)
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected unready with a full spool, got %d", code)
+	}
+	details := report.Components["spool"].Details.(map[string]interface{})
+	if details["dropped_events"] == float64(0) {
+		t.Errorf("Expected dropped events in the report, got %v", details)
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewHealthChecker().ServeLiveness(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected liveness response %d %v", recorder.Code, recorder.Header())
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// importCheckpointFileName returns the name of the checkpoint written next
+// to imported files unless -checkpoint is given. Every set of sinks gets its
+// own checkpoint, so the same files can later be imported into new sinks.
+func importCheckpointFileName(sinks []SinkConfig) string {
+	names := make([]string, len(sinks))
+	for i, sink := range sinks {
+		names[i] = sink.Name
+	}
+	sort.Strings(names)
+	return ".import_checkpoint_" + strings.Join(names, "_") + ".json"
+}
+
+// FileCheckpoint records how far a backup file has been imported
+type FileCheckpoint struct {
+	Offset    int64     `json:"offset"` // Uncompressed bytes committed to storage
+	Events    int       `json:"events"`
+	Complete  bool      `json:"complete"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ImportCheckpoint records the files and byte offsets committed by import,
+// so an interrupted import resumes where it stopped. It is saved after every
+// committed batch.
+type ImportCheckpoint struct {
+	path  string
+	mu    sync.Mutex
+	Files map[string]*FileCheckpoint `json:"files"`
+}
+
+// LoadImportCheckpoint reads the checkpoint at path, returning an empty one
+// when it does not exist yet
+func LoadImportCheckpoint(path string) (*ImportCheckpoint, error) {
+	checkpoint := &ImportCheckpoint{path: path, Files: make(map[string]*FileCheckpoint)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint %s: %w", path, err)
+	}
+	if checkpoint.Files == nil {
+		checkpoint.Files = make(map[string]*FileCheckpoint)
+	}
+	return checkpoint, nil
+}
+
+// checkpointKey identifies a backup file in the checkpoint. Offsets are
+// counted in uncompressed bytes, so a file keeps its progress when it is
+// compressed after a partial import.
+func checkpointKey(file string) string {
+	return strings.TrimSuffix(filepath.Base(file), ".gz")
+}
+
+// Get returns the progress of a file
+func (c *ImportCheckpoint) Get(file string) FileCheckpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if progress, ok := c.Files[checkpointKey(file)]; ok {
+		return *progress
+	}
+	return FileCheckpoint{}
+}
+
+// Commit records the progress of a file and saves the checkpoint
+func (c *ImportCheckpoint) Commit(file string, offset int64, events int, complete bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Files[checkpointKey(file)] = &FileCheckpoint{
+		Offset:    offset,
+		Events:    events,
+		Complete:  complete,
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	tmpPath := c.path + ".tmp"
+	if err := writeFileSync(tmpPath, data); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace checkpoint: %w", err)
+	}
+	return nil
+}
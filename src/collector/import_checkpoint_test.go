@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// writeBackupFile stores events in a new backup file and returns its path
+func writeBackupFile(t *testing.T, dir string, compression string, events []Event) string {
+	t.Helper()
+	client, err := NewFileBackupClient(FileBackupConfig{Enabled: true, BasePath: dir, Compression: compression})
+	if err != nil {
+		t.Fatalf("Failed to create file backup client: %v", err)
+	}
+	if err := client.StoreEvents(events); err != nil {
+		t.Fatalf("Failed to store events: %v", err)
+	}
+	client.Close()
+
+	files, _ := backupFiles(dir)
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 backup file, got %v", files)
+	}
+	return files[0]
+}
+
+func TestImportResumesFromCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	var events []Event
+	for i := 1; i <= 5; i++ {
+		events = append(events, sequencedEvent(i))
+	}
+	file := writeBackupFile(t, dir, FileCompressionGzip, events)
+	checkpointPath := filepath.Join(dir, importCheckpointFileName(nil))
+
+	// Batches with the third event fail
+	var stored []Event
+	failing := &mockDBClient{storeEventsFunc: func(batch []Event) error {
+		for _, event := range batch {
+			if event.Sequence == 3 {
+				return errors.New("connection refused")
+			}
+		}
+		stored = append(stored, batch...)
+		return nil
+	}}
+
+	checkpoint, err := LoadImportCheckpoint(checkpointPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	summary := importFiles([]string{file}, failing, ImportOptions{BatchSize: 2}, checkpoint)
+	if len(summary.Failed) != 1 || summary.Events == 0 || summary.Events != len(stored) {
+		t.Fatalf("Expected a failed file after the first batch, got %+v", summary)
+	}
+
+	// A new run resumes after the committed batches
+	checkpoint, err = LoadImportCheckpoint(checkpointPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if progress := checkpoint.Get(file); progress.Offset == 0 || progress.Complete || progress.Events != len(stored) {
+		t.Fatalf("Unexpected checkpoint: %+v", progress)
+	}
+	recording := &mockDBClient{storeEventsFunc: func(batch []Event) error {
+		stored = append(stored, batch...)
+		return nil
+	}}
+	summary = importFiles([]string{file}, recording, ImportOptions{BatchSize: 2}, checkpoint)
+	if len(summary.Failed) != 0 || summary.Resumed != 1 || len(stored) != 5 {
+		t.Fatalf("Unexpected summary after resuming: %+v", summary)
+	}
+	for i, event := range stored {
+		if event.Sequence != uint64(i+1) {
+			t.Fatalf("Expected every event once and in order, got %d at position %d", event.Sequence, i)
+		}
+	}
+
+	// Complete files are skipped
+	summary = importFiles([]string{file}, recording, ImportOptions{BatchSize: 2}, checkpoint)
+	if summary.Done != 1 || summary.Events != 0 {
+		t.Errorf("Expected the imported file to be skipped, got %+v", summary)
+	}
+	if progress := checkpoint.Get(file); !progress.Complete || progress.Events != 5 {
+		t.Errorf("Unexpected final checkpoint: %+v", progress)
+	}
+}
+
+func TestImportSkipsUndecodableLines(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "events_20250420_210000.jsonl")
+	content := `{"type":"ROUND_OVER","data":{"ROUND":1}}
+not json
+{"type":"ROUND_OVER","data":{"ROUND":2}}
+`
+	if err := writeFileSync(file, []byte(content)); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	var stored []Event
+	client := &mockDBClient{storeEventsFunc: func(batch []Event) error {
+		stored = append(stored, batch...)
+		return nil
+	}}
+	result, err := ImportEventsFromOffset(file, client, 10, 0, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(stored) != 2 || result.Skipped != 1 || !result.Complete || result.Offset != int64(len(content)) {
+		t.Errorf("Unexpected result %+v with %d events", result, len(stored))
+	}
+}
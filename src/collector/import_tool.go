@@ -7,9 +7,11 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 )
 
-// ImportTool is a simple command-line tool to import event data from backup files to the database
+// ImportTool is a simple command-line tool to import event data from backup
+// files into the configured storage sinks
 func ImportTool() {
 	// Only run if we're specifically using the import command
 	if len(os.Args) < 2 || os.Args[1] != "import" {
@@ -22,6 +24,11 @@ func ImportTool() {
 	filePath := importCmd.String("file", "", "Path to event file to import (required)")
 	dirPath := importCmd.String("dir", "", "Directory containing event files to import")
 	batchSize := importCmd.Int("batch", 100, "Batch size for importing events")
+	workers := importCmd.Int("workers", 1, "Number of files decoded and batches stored concurrently (events of a match stay in order)")
+	showProgress := importCmd.Bool("progress", true, "Show a progress bar with throughput and ETA")
+	checkpointPath := importCmd.String("checkpoint", "", "Path to the checkpoint file (default: one per set of sinks next to the imported files)")
+	fromScratch := importCmd.Bool("from-scratch", false, "Ignore the checkpoint and import every file from the start")
+	sinkNames := importCmd.String("sinks", "", "Comma-separated names of the configured sinks to import into (default: all except file_backup sinks)")
 
 	// Parse import flags (skip the "import" arg)
 	if err := importCmd.Parse(os.Args[2:]); err != nil {
@@ -34,33 +41,29 @@ func ImportTool() {
 	}
 
 	// Load configuration
-	flag.Set("config", *configPath) // Set the config flag for loadConfig
-	cfg := loadConfig()
+	cfg := loadConfigFile(*configPath)
 
-	// Ensure PostgreSQL is enabled in the config
-	if !cfg.PostgresEnabled {
-		log.Fatalf("Error: PostgreSQL must be enabled in the configuration for import")
-	}
-
-	// Create PostgreSQL client
-	dbClient, err := NewPostgresClient(cfg)
+	sinks, err := selectImportSinks(cfg.Sinks, *sinkNames)
 	if err != nil {
-		log.Fatalf("Error connecting to PostgreSQL: %v", err)
+		log.Fatalf("Error: %v", err)
+	}
+	if len(sinks) == 0 {
+		log.Fatalf("Error: No storage sinks to import into, configure sinks or use -sinks")
 	}
-	defer dbClient.Close()
+	cfg.Sinks = sinks
+	cfg = importStorageConfig(cfg)
 
-	// Process a single file
+	var files []string
 	if *filePath != "" {
-		if err := ImportEventsFromFile(*filePath, dbClient, *batchSize); err != nil {
-			log.Fatalf("Error importing from file %s: %v", *filePath, err)
+		// Process a single file
+		files = []string{*filePath}
+		if *checkpointPath == "" {
+			*checkpointPath = filepath.Join(filepath.Dir(*filePath), importCheckpointFileName(sinks))
 		}
-		fmt.Println("Import completed successfully")
-		os.Exit(0)
-	}
-
-	// Process a directory of files
-	if *dirPath != "" {
-		files, err := filepath.Glob(filepath.Join(*dirPath, "events_*.jsonl"))
+	} else {
+		// Process a directory of plain and gzipped files, sorted by name
+		// (which includes timestamp)
+		files, err = backupFiles(*dirPath)
 		if err != nil {
 			log.Fatalf("Error finding event files: %v", err)
 		}
@@ -68,23 +71,121 @@ func ImportTool() {
 		if len(files) == 0 {
 			log.Fatalf("No event files found in directory %s", *dirPath)
 		}
-
+		if *checkpointPath == "" {
+			*checkpointPath = filepath.Join(*dirPath, importCheckpointFileName(sinks))
+		}
 		fmt.Printf("Found %d event files to import\n", len(files))
-		
-		// Sort files by name (which includes timestamp)
-		// This isn't a perfect sort by date, but works for the filename format we use
-		// events_YYYYMMDD_HHMMSS.jsonl
-		sort.Strings(files)
-		
-		for i, file := range files {
-			fmt.Printf("[%d/%d] Importing file: %s\n", i+1, len(files), filepath.Base(file))
-			if err := ImportEventsFromFile(file, dbClient, *batchSize); err != nil {
-				log.Printf("Error importing from file %s: %v", file, err)
-				// Continue with the next file
+	}
+
+	checkpoint, err := LoadImportCheckpoint(*checkpointPath)
+	if err != nil {
+		log.Fatalf("Error loading checkpoint (use -from-scratch to ignore it): %v", err)
+	}
+	if *fromScratch {
+		checkpoint.Files = make(map[string]*FileCheckpoint)
+	}
+
+	// Create the storage client, a multi-client when importing into several
+	// sinks
+	for _, sink := range sinks {
+		fmt.Printf("Importing into sink %s (%s)\n", sink.Name, sink.Type)
+	}
+	dbClient := newStorageClient(cfg, nil)
+	if dbClient == nil {
+		log.Fatalf("Error: Failed to initialize the storage sinks")
+	}
+
+	options := ImportOptions{BatchSize: *batchSize, Workers: *workers}
+	if *showProgress {
+		options.Progress = os.Stdout
+	}
+	summary := importFiles(files, dbClient, options, checkpoint)
+	dbClient.Close()
+
+	summary.Print()
+	if len(summary.Failed) > 0 {
+		fmt.Printf("Re-run the command to resume from %s\n", *checkpointPath)
+		os.Exit(1)
+	}
+	fmt.Println("Import completed successfully")
+	os.Exit(0)
+}
+
+// selectImportSinks returns the configured sinks named in the comma-separated
+// names. Without names it returns every sink except file_backup sinks, so
+// backups are not written back into backup files by default.
+func selectImportSinks(sinks []SinkConfig, names string) ([]SinkConfig, error) {
+	var selected []SinkConfig
+	if strings.TrimSpace(names) == "" {
+		for _, sink := range sinks {
+			if sink.Type != SinkTypeFileBackup {
+				selected = append(selected, sink)
 			}
 		}
-		
-		fmt.Println("Import completed successfully")
-		os.Exit(0)
+		return selected, nil
+	}
+
+	byName := make(map[string]SinkConfig, len(sinks))
+	for _, sink := range sinks {
+		byName[sink.Name] = sink
+	}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		sink, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown sink %q", name)
+		}
+		selected = append(selected, sink)
+	}
+	return selected, nil
+}
+
+// importStorageConfig adjusts the storage settings for an import, so the
+// checkpoint only moves past a batch once every sink has written it
+func importStorageConfig(cfg Config) Config {
+	if cfg.SinkWritePolicy == WritePolicyAny || cfg.SinkWritePolicy == WritePolicyAsync {
+		log.Printf("Importing with write policy %s instead of %s", WritePolicyAll, cfg.SinkWritePolicy)
+		cfg.SinkWritePolicy = WritePolicyAll
+	}
+
+	// A batch spooled by an open circuit breaker has not been written, and the
+	// spool directory belongs to the running collector
+	if cfg.SinkBreakerOpenAction == BreakerOpenSpool {
+		log.Printf("Importing with breaker open action %s instead of %s", BreakerOpenFail, BreakerOpenSpool)
+		cfg.SinkBreakerOpenAction = BreakerOpenFail
+	}
+	return cfg
+}
+
+// ImportSummary summarizes an import of several files
+type ImportSummary struct {
+	Files   int               // Files imported in this run, fully or partially
+	Resumed int               // Files resumed from a checkpointed offset
+	Done    int               // Files skipped because the checkpoint marks them complete
+	Events  int               // Events stored
+	Skipped int               // Lines that could not be decoded
+	Failed  map[string]string // Files that failed and their error
+}
+
+// Print writes the summary to stdout
+func (s ImportSummary) Print() {
+	fmt.Printf("\nImported %d events from %d files (%d resumed, %d already imported)\n",
+		s.Events, s.Files, s.Resumed, s.Done)
+	if s.Skipped > 0 {
+		fmt.Printf("Skipped %d lines that could not be decoded\n", s.Skipped)
+	}
+	if len(s.Failed) > 0 {
+		fmt.Printf("%d files failed:\n", len(s.Failed))
+		files := make([]string, 0, len(s.Failed))
+		for file := range s.Failed {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			fmt.Printf("  %s: %s\n", file, s.Failed[file])
+		}
 	}
-} 
\ No newline at end of file
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSelectImportSinks(t *testing.T) {
+	sinks := []SinkConfig{
+		{Name: "main", Type: SinkTypePostgres},
+		{Name: "stats", Type: SinkTypePostgresRelational},
+		{Name: "local", Type: SinkTypeFileBackup},
+	}
+
+	// File backups are left out by default
+	selected, err := selectImportSinks(sinks, "")
+	if err != nil || len(selected) != 2 || selected[0].Name != "main" || selected[1].Name != "stats" {
+		t.Errorf("Unexpected default sinks %+v (%v)", selected, err)
+	}
+
+	selected, err = selectImportSinks(sinks, "local, stats")
+	if err != nil || len(selected) != 2 || selected[0].Name != "local" || selected[1].Name != "stats" {
+		t.Errorf("Unexpected selected sinks %+v (%v)", selected, err)
+	}
+
+	if _, err := selectImportSinks(sinks, "replica"); err == nil {
+		t.Error("Expected an error for an unknown sink")
+	}
+}
+
+func TestImportStorageConfig(t *testing.T) {
+	cfg := importStorageConfig(Config{
+		SinkWritePolicy:       WritePolicyAsync,
+		SinkBreakerThreshold:  1,
+		SinkBreakerOpenAction: BreakerOpenSpool,
+		SinkBreakerSpoolPath:  t.TempDir(),
+	})
+	if cfg.SinkWritePolicy != WritePolicyAll {
+		t.Errorf("Expected write policy %s, got %s", WritePolicyAll, cfg.SinkWritePolicy)
+	}
+
+	// An open circuit fails the batch instead of spooling it
+	client := NewResilientClient("main", &mockDBClient{}, cfg.ResilienceConfig("main"))
+	if cfg.SinkBreakerOpenAction != BreakerOpenFail || client.spool != nil {
+		t.Errorf("Expected breaker open action %s without a spool, got %s", BreakerOpenFail, cfg.SinkBreakerOpenAction)
+	}
+}
+
+func TestImportIntoMultipleSinks(t *testing.T) {
+	dir := t.TempDir()
+	file := writeBackupFile(t, dir, FileCompressionNone, []Event{sequencedEvent(1), sequencedEvent(2)})
+
+	// Import into two file sinks as the multi-client would with the all policy
+	targets := []string{t.TempDir(), t.TempDir()}
+	cfg := Config{
+		SinkWritePolicy: WritePolicyAll,
+		Sinks: []SinkConfig{
+			{Name: "a", Type: SinkTypeFileBackup, Options: map[string]interface{}{"path": targets[0]}},
+			{Name: "b", Type: SinkTypeFileBackup, Options: map[string]interface{}{"path": targets[1]}},
+		},
+	}
+	client := newStorageClient(cfg, nil)
+	if _, ok := client.(*MultiDBClient); !ok {
+		t.Fatalf("Expected a multi-client, got %T", client)
+	}
+
+	checkpoint, _ := LoadImportCheckpoint(filepath.Join(dir, importCheckpointFileName(cfg.Sinks)))
+	summary := importFiles([]string{file}, client, ImportOptions{BatchSize: 10}, checkpoint)
+	client.Close()
+	if summary.Events != 2 || len(summary.Failed) != 0 {
+		t.Fatalf("Unexpected summary %+v", summary)
+	}
+
+	for _, target := range targets {
+		var imported int
+		files, _ := backupFiles(target)
+		for _, f := range files {
+			result, _ := ImportEventsFromOffset(f, &mockDBClient{}, 10, 0, nil)
+			imported += result.Events
+		}
+		if imported != 2 {
+			t.Errorf("Expected 2 events in %s, got %d", target, imported)
+		}
+	}
+}
+
+func TestImportCheckpointPerSinks(t *testing.T) {
+	a := importCheckpointFileName([]SinkConfig{{Name: "main"}, {Name: "stats"}})
+	b := importCheckpointFileName([]SinkConfig{{Name: "stats"}, {Name: "main"}})
+	c := importCheckpointFileName([]SinkConfig{{Name: "replica"}})
+	if a != b || a == c {
+		t.Errorf("Expected one checkpoint per set of sinks, got %s, %s and %s", a, b, c)
+	}
+}
+
+func TestImportWorkersKeepMatchOrder(t *testing.T) {
+	dir := t.TempDir()
+
+	// Three matches interleaved over two files
+	var sequence int
+	for file := 0; file < 2; file++ {
+		var events []Event
+		for i := 0; i < 30; i++ {
+			sequence++
+			events = append(events, Event{
+				Type:     "PLAYER_KILL",
+				Data:     json.RawMessage(fmt.Sprintf(`{"MATCH_GUID":"match-%d","TIME":%d}`, sequence%3, sequence)),
+				Sequence: uint64(sequence),
+			})
+		}
+		fileDir := filepath.Join(dir, fmt.Sprint(file))
+		source := writeBackupFile(t, fileDir, FileCompressionNone, events)
+		os.Rename(source, filepath.Join(dir, fmt.Sprintf("events_20250420_21000%d.jsonl", file)))
+	}
+	files, _ := backupFiles(dir)
+
+	var mu sync.Mutex
+	lastByMatch := make(map[string]uint64)
+	outOfOrder := 0
+	client := &mockDBClient{storeEventsFunc: func(batch []Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, event := range batch {
+			key := importOrderKey(event)
+			if event.Sequence < lastByMatch[key] {
+				outOfOrder++
+			}
+			lastByMatch[key] = event.Sequence
+		}
+		return nil
+	}}
+
+	checkpoint, _ := LoadImportCheckpoint(filepath.Join(dir, importCheckpointFileName(nil)))
+	var progress bytes.Buffer
+	summary := importFiles(files, client, ImportOptions{BatchSize: 4, Workers: 3, Progress: &progress}, checkpoint)
+	if summary.Events != 60 || len(summary.Failed) != 0 {
+		t.Fatalf("Unexpected summary %+v", summary)
+	}
+	if outOfOrder > 0 {
+		t.Errorf("Expected the events of every match in order, got %d out of order", outOfOrder)
+	}
+	for _, file := range files {
+		if progress := checkpoint.Get(file); !progress.Complete || progress.Events != 30 {
+			t.Errorf("Unexpected checkpoint for %s: %+v", file, progress)
+		}
+	}
+	if !strings.Contains(progress.String(), "100.0%") || !strings.Contains(progress.String(), "60 events") {
+		t.Errorf("Unexpected progress output %q", progress.String())
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
@@ -29,6 +30,18 @@ func main() {
 	// Check if we're running the import tool
 	ImportTool()
 	
+	// Check if we're running the export tool
+	ExportTool()
+	
+	// Check if we're running the replay tool
+	ReplayTool()
+	
+	// Check if we're running the match simulator
+	SimulateTool()
+	
+	// Check if we're running the dead-letter tool
+	DeadLetterTool()
+	
 	runtime.GOMAXPROCS(2)
 	
 	// Load configuration
@@ -42,56 +55,74 @@ func main() {
 	// Setup signal handling
 	setupSignalHandling(cancel)
 
+	// Collect Prometheus metrics for the whole pipeline
+	metrics := NewCollectorMetrics()
+
 	// Initialize storage clients
-	var dbClients []DBClient
+	storageClient := newStorageClient(cfg, metrics)
+	if storageClient != nil {
+		defer storageClient.Close()
+	}
+
+	// Create event processor
+	processor := NewEventProcessor(cfg, storageClient)
+	processor.SetMetrics(metrics)
 
-	// Initialize PostgreSQL client if enabled
-	if cfg.PostgresEnabled {
-		dbClient, err := NewPostgresClient(cfg)
+	// Keep live state of in-progress matches
+	if cfg.MatchTrackerEnabled {
+		tracker := NewMatchTracker(time.Duration(cfg.MatchTrackerTimeoutMin) * time.Minute)
+		processor.AddConsumer(tracker)
+		metrics.Registry.NewGaugeFunc("active_matches", "Matches currently in progress.",
+			func() []GaugeSample {
+				return []GaugeSample{{Value: float64(len(tracker.Matches("")))}}
+			})
+	}
+	
+	// Validate events before processing, quarantining rejected payloads
+	var deadLetterSink DeadLetterSink
+	if cfg.DeadLetterEnabled {
+		sink, err := NewFileDeadLetterSink(cfg.DeadLetterPath)
 		if err != nil {
-			log.Printf("Warning: Failed to initialize PostgreSQL client: %v", err)
-		} else if dbClient != nil {
-			dbClients = append(dbClients, dbClient)
-			defer dbClient.Close()
+			log.Printf("Warning: Failed to initialize dead-letter sink: %v", err)
+		} else {
+			deadLetterSink = sink
+			defer sink.Close()
 		}
 	}
+	validator := NewEventValidator(processor, deadLetterSink)
+	validator.SetMetrics(metrics)
 
-	// Initialize file backup client if enabled
-	if cfg.FileBackupEnabled {
-		fileBackupConfig := FileBackupConfig{
-			Enabled:     cfg.FileBackupEnabled,
-			BasePath:    cfg.FileBackupPath,
-			MaxFileSize: int64(cfg.FileBackupMaxSizeMB) * 1024 * 1024, // Convert MB to bytes
-			MaxFileAge:  time.Duration(cfg.FileBackupMaxAgeHours) * time.Hour,
+	// Serve metrics and health checks over HTTP if enabled
+	if cfg.HTTPAddr != "" {
+		health := NewHealthChecker()
+		if cfg.ReadyMaxIdleMin > 0 {
+			maxIdle := time.Duration(cfg.ReadyMaxIdleMin) * time.Minute
+			health.AddCheck("zmq", zmqActivityCheck(validator, cfg.Servers, maxIdle))
 		}
-		
-		fileClient, err := NewFileBackupClient(fileBackupConfig)
-		if err != nil {
-			log.Printf("Warning: Failed to initialize file backup client: %v", err)
-		} else if fileClient != nil {
-			dbClients = append(dbClients, fileClient)
-			defer fileClient.Close()
+		if reporter, ok := storageClient.(SinkHealthReporter); ok {
+			health.AddCheck("storage", storageCheck(reporter))
+		}
+		if spool := processor.Spool(); spool != nil {
+			health.AddCheck("spool", spoolCheck(spool))
+		}
+		if cfg.ReadyChannelSaturationPct > 0 {
+			health.AddCheck("processor", channelCheck(processor, float64(cfg.ReadyChannelSaturationPct)/100))
 		}
-	}
 
-	// Create a multi-client if we have multiple storage options
-	var storageClient DBClient
-	if len(dbClients) > 1 {
-		storageClient = NewMultiDBClient(dbClients)
-	} else if len(dbClients) == 1 {
-		storageClient = dbClients[0]
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Registry)
+		mux.HandleFunc("/healthz", health.ServeLiveness)
+		mux.HandleFunc("/readyz", health.ServeReadiness)
+		startHTTPServer(ctx, cfg.HTTPAddr, mux)
 	}
-
-	// Create event processor
-	processor := NewEventProcessor(cfg, storageClient)
 	
 	// Create ZMQ collector factory
-	createZmqCollector := func(config *Config, proc EventProcessorInterface) (Collector, error) {
-		return NewZmqCollector(config.ZmqEndpoint, proc)
+	createZmqCollector := func(server ServerConfig, proc EventProcessorInterface) (Collector, error) {
+		return NewZmqCollector(server, proc)
 	}
 
 	// Create collector manager
-	manager, err := NewCollectorManager(&cfg, processor, createZmqCollector)
+	manager, err := NewCollectorManager(&cfg, validator, createZmqCollector)
 	if err != nil {
 		log.Fatalf("Failed to create collector manager: %v", err)
 	}
@@ -100,4 +131,42 @@ func main() {
 	manager.Run(ctx)
 
 	log.Println("Collector shut down")
-} 
\ No newline at end of file
+}
+
+// newStorageClient creates a storage client for every entry of the sinks
+// list. Each client is wrapped in a ResilientClient and instrumented in
+// metrics unless metrics is nil. It returns nil when no storage is enabled.
+func newStorageClient(cfg Config, metrics *CollectorMetrics) DBClient {
+	var dbClients []DBClient
+
+	// Every sink retries failed writes behind a circuit breaker and is measured
+	sink := func(name string, client DBClient) DBClient {
+		return metrics.InstrumentSink(name, NewResilientClient(name, client, cfg.ResilienceConfig(name)))
+	}
+
+	sinks := cfg.SinkList()
+	if err := validateSinks(cfg, sinks); err != nil {
+		log.Fatalf("Invalid sinks configuration: %v", err)
+	}
+
+	for _, sinkConfig := range sinks {
+		client, err := newSink(cfg, sinkConfig)
+		if err != nil {
+			log.Printf("Warning: Failed to initialize sink %s (%s): %v", sinkConfig.Name, sinkConfig.Type, err)
+		} else if client != nil {
+			dbClients = append(dbClients, sink(sinkConfig.Name, client))
+		}
+	}
+
+	// Create a multi-client if we have multiple storage options
+	if len(dbClients) > 1 {
+		multiClient := NewMultiDBClient(dbClients, cfg.MultiClientConfig())
+		if metrics != nil {
+			multiClient.(*MultiDBClient).SetMetrics(metrics)
+		}
+		return multiClient
+	} else if len(dbClients) == 1 {
+		return dbClients[0]
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"testing"
 	"time"
@@ -15,6 +16,7 @@ type mockCollector struct {
 	stopped  bool
 	runError error
 	stopChan chan struct{}
+	mu       sync.Mutex
 }
 
 func newMockCollector() *mockCollector {
@@ -24,7 +26,9 @@ func newMockCollector() *mockCollector {
 }
 
 func (m *mockCollector) Run(ctx context.Context) error {
+	m.mu.Lock()
 	m.started = true
+	m.mu.Unlock()
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
@@ -34,10 +38,24 @@ func (m *mockCollector) Run(ctx context.Context) error {
 }
 
 func (m *mockCollector) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.stopped = true
 	close(m.stopChan)
 }
 
+func (m *mockCollector) isStarted() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.started
+}
+
+func (m *mockCollector) isStopped() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stopped
+}
+
 // runWithCollector simulates running the application with a specific collector
 func runWithCollector(ctx context.Context, collector Collector) {
 	// Set up signal handling
@@ -86,7 +104,7 @@ func TestSignalHandling(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 	
 	// Verify collector was started
-	if !collector.started {
+	if !collector.isStarted() {
 		t.Fatal("Collector should have been started")
 	}
 	
@@ -102,7 +120,7 @@ func TestSignalHandling(t *testing.T) {
 	}
 	
 	// Verify collector was stopped
-	if !collector.stopped {
+	if !collector.isStopped() {
 		t.Fatal("Collector should have been stopped")
 	}
 }
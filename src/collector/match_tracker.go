@@ -0,0 +1,294 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"quake-stats/qlstats"
+)
+
+// EventConsumer receives every event the processor accepts, in the order
+// they were submitted
+type EventConsumer interface {
+	ConsumeEvent(e Event)
+}
+
+// EventSweeper is implemented by consumers that expire state over time. The
+// processor calls Sweep periodically so state is freed while servers are quiet.
+type EventSweeper interface {
+	Sweep()
+}
+
+// PlayerState is the live state of a player in a match
+type PlayerState struct {
+	SteamID   string
+	Name      string
+	Team      qlstats.Team
+	Frags     int
+	Deaths    int
+	Connected bool
+}
+
+// MatchState is the live state of an in-progress match
+type MatchState struct {
+	MatchGUID   string
+	Server      string
+	GameType    string
+	Factory     string
+	Map         string
+	Started     bool // MATCH_STARTED has been seen, i.e. the match left warmup
+	StartedAt   time.Time
+	LastEventAt time.Time
+	Elapsed     int // Seconds since the match started, from the latest event TIME
+	Round       int
+	RoundsWon   map[qlstats.Team]int
+	Players     map[string]*PlayerState // Keyed by Steam ID
+}
+
+// copy returns a deep copy of the match state
+func (m *MatchState) copy() MatchState {
+	c := *m
+	c.RoundsWon = make(map[qlstats.Team]int, len(m.RoundsWon))
+	for team, rounds := range m.RoundsWon {
+		c.RoundsWon[team] = rounds
+	}
+	c.Players = make(map[string]*PlayerState, len(m.Players))
+	for id, player := range m.Players {
+		p := *player
+		c.Players[id] = &p
+	}
+	return c
+}
+
+// MatchTracker keeps a live model of every in-progress match, keyed by
+// MATCH_GUID. Matches are removed on MATCH_REPORT, or evicted when no event
+// has been received for the idle timeout.
+type MatchTracker struct {
+	matches     map[string]*MatchState
+	idleTimeout time.Duration
+	mu          sync.RWMutex
+	now         func() time.Time
+	lastSweep   time.Time
+	// Metrics
+	matchesStarted  int64
+	matchesFinished int64
+	matchesEvicted  int64
+}
+
+// NewMatchTracker creates a new match tracker
+func NewMatchTracker(idleTimeout time.Duration) *MatchTracker {
+	if idleTimeout <= 0 {
+		idleTimeout = 30 * time.Minute
+	}
+	return &MatchTracker{
+		matches:     make(map[string]*MatchState),
+		idleTimeout: idleTimeout,
+		now:         time.Now,
+	}
+}
+
+// ConsumeEvent implements the EventConsumer interface
+func (t *MatchTracker) ConsumeEvent(e Event) {
+	decoded, err := e.Decode()
+	if err != nil || decoded.MatchID() == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.sweep(now)
+
+	guid := decoded.MatchID()
+
+	// The match has ended, drop it from the live set
+	if report, ok := decoded.(*qlstats.MatchReport); ok {
+		if _, exists := t.matches[report.MatchGUID]; exists {
+			delete(t.matches, report.MatchGUID)
+			t.matchesFinished++
+		}
+		return
+	}
+
+	match := t.matches[guid]
+	if match == nil {
+		match = &MatchState{
+			MatchGUID: guid,
+			Server:    e.Server,
+			StartedAt: now,
+			RoundsWon: make(map[qlstats.Team]int),
+			Players:   make(map[string]*PlayerState),
+		}
+		t.matches[guid] = match
+	}
+	match.LastEventAt = now
+
+	switch ev := decoded.(type) {
+	case *qlstats.MatchStarted:
+		match.Started = true
+		match.StartedAt = now
+		match.GameType = ev.GameType
+		match.Factory = ev.Factory
+		match.Map = ev.Map
+		match.Elapsed = 0
+		match.Round = 0
+		match.RoundsWon = make(map[qlstats.Team]int)
+		// Warmup frags do not count, start from a clean scoreboard
+		for _, player := range match.Players {
+			player.Frags = 0
+			player.Deaths = 0
+		}
+		for _, p := range ev.Players {
+			player := match.player(p.SteamID, p.Name)
+			player.Team = p.Team
+			player.Connected = true
+		}
+		t.matchesStarted++
+
+	case *qlstats.PlayerConnect:
+		match.Elapsed = ev.Time
+		match.player(ev.SteamID, ev.Name).Connected = true
+
+	case *qlstats.PlayerDisconnect:
+		match.Elapsed = ev.Time
+		match.player(ev.SteamID, ev.Name).Connected = false
+
+	case *qlstats.PlayerSwitchTeam:
+		match.Elapsed = ev.Time
+		match.player(ev.Player.SteamID, ev.Player.Name).Team = ev.Player.Team
+
+	case *qlstats.PlayerKill:
+		match.updateFromKill(&ev.Kill)
+		if ev.Warmup || ev.Killer == nil {
+			break
+		}
+		killer := match.player(ev.Killer.SteamID, ev.Killer.Name)
+		if ev.Suicide || ev.TeamKill {
+			killer.Frags--
+		} else {
+			killer.Frags++
+		}
+
+	case *qlstats.PlayerDeath:
+		match.updateFromKill(&ev.Kill)
+		if ev.Warmup {
+			break
+		}
+		victim := match.player(ev.Victim.SteamID, ev.Victim.Name)
+		victim.Deaths++
+		// World deaths (falling, lava) cost a frag and have no PLAYER_KILL
+		if ev.Killer == nil {
+			victim.Frags--
+		}
+
+	case *qlstats.RoundOver:
+		match.Elapsed = ev.Time
+		match.Round = ev.Round
+		if !ev.Warmup && ev.TeamWon != qlstats.TeamDraw {
+			match.RoundsWon[ev.TeamWon]++
+		}
+	}
+}
+
+// updateFromKill updates match progress from a kill or death
+func (m *MatchState) updateFromKill(kill *qlstats.Kill) {
+	m.Elapsed = kill.Time
+	if kill.Round != nil {
+		m.Round = *kill.Round
+	}
+}
+
+// player returns the player with the given Steam ID, adding it if needed
+func (m *MatchState) player(steamID, name string) *PlayerState {
+	player := m.Players[steamID]
+	if player == nil {
+		player = &PlayerState{SteamID: steamID}
+		m.Players[steamID] = player
+	}
+	if name != "" {
+		player.Name = name
+	}
+	return player
+}
+
+// Sweep implements the EventSweeper interface, evicting matches without
+// events for the idle timeout
+func (t *MatchTracker) Sweep() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.evict(t.now())
+}
+
+// sweep evicts idle matches at most once a minute. Must be called with the
+// write lock held.
+func (t *MatchTracker) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < time.Minute {
+		return
+	}
+	t.evict(now)
+}
+
+// evict evicts matches without events for the idle timeout. Must be called
+// with the write lock held.
+func (t *MatchTracker) evict(now time.Time) {
+	t.lastSweep = now
+
+	for guid, match := range t.matches {
+		if now.Sub(match.LastEventAt) > t.idleTimeout {
+			delete(t.matches, guid)
+			t.matchesEvicted++
+		}
+	}
+}
+
+// Match returns a copy of the state of an in-progress match
+func (t *MatchTracker) Match(guid string) (MatchState, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	match, ok := t.matches[guid]
+	if !ok || t.now().Sub(match.LastEventAt) > t.idleTimeout {
+		return MatchState{}, false
+	}
+	return match.copy(), true
+}
+
+// Matches returns copies of all in-progress matches, optionally limited to
+// one server, ordered by start time
+func (t *MatchTracker) Matches(server string) []MatchState {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	now := t.now()
+	matches := make([]MatchState, 0, len(t.matches))
+	for _, match := range t.matches {
+		if server != "" && match.Server != server {
+			continue
+		}
+		if now.Sub(match.LastEventAt) > t.idleTimeout {
+			continue
+		}
+		matches = append(matches, match.copy())
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].StartedAt.Before(matches[j].StartedAt)
+	})
+	return matches
+}
+
+// GetMetrics returns metrics about the tracked matches
+func (t *MatchTracker) GetMetrics() map[string]interface{} {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return map[string]interface{}{
+		"active_matches":       len(t.matches),
+		"matches_started":      t.matchesStarted,
+		"matches_finished":     t.matchesFinished,
+		"matches_evicted":      t.matchesEvicted,
+		"idle_timeout_minutes": t.idleTimeout.Minutes(),
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"quake-stats/qlstats"
+)
+
+const trackerMatchGUID = "0a4c5e6f-1111-2222-3333-444455556666"
+
+// trackerEvent builds an event for the tracker test match
+func trackerEvent(eventType, data string) Event {
+	return Event{Type: eventType, Server: "ca", Data: json.RawMessage(data)}
+}
+
+func TestMatchTrackerScoreboard(t *testing.T) {
+	tracker := NewMatchTracker(time.Minute)
+
+	events := []Event{
+		trackerEvent("PLAYER_CONNECT", `{"MATCH_GUID":"`+trackerMatchGUID+`","NAME":"alice","STEAM_ID":"1","TIME":0,"WARMUP":true}`),
+		trackerEvent("PLAYER_KILL", `{"MATCH_GUID":"`+trackerMatchGUID+`","TIME":5,"WARMUP":true,"SUICIDE":false,"TEAMKILL":false,
+			"KILLER":{"NAME":"alice","STEAM_ID":"1","TEAM":1},"VICTIM":{"NAME":"bob","STEAM_ID":"2","TEAM":2}}`),
+		trackerEvent("MATCH_STARTED", `{"MATCH_GUID":"`+trackerMatchGUID+`","GAME_TYPE":"CA","FACTORY":"ca","MAP":"campgrounds",
+			"PLAYERS":[{"NAME":"alice","STEAM_ID":"1","TEAM":1},{"NAME":"bob","STEAM_ID":"2","TEAM":2}]}`),
+		trackerEvent("PLAYER_KILL", `{"MATCH_GUID":"`+trackerMatchGUID+`","TIME":20,"ROUND":1,"WARMUP":false,"SUICIDE":false,"TEAMKILL":false,
+			"KILLER":{"NAME":"alice","STEAM_ID":"1","TEAM":1},"VICTIM":{"NAME":"bob","STEAM_ID":"2","TEAM":2}}`),
+		trackerEvent("PLAYER_DEATH", `{"MATCH_GUID":"`+trackerMatchGUID+`","TIME":20,"ROUND":1,"WARMUP":false,"SUICIDE":false,"TEAMKILL":false,
+			"KILLER":{"NAME":"alice","STEAM_ID":"1","TEAM":1},"VICTIM":{"NAME":"bob","STEAM_ID":"2","TEAM":2}}`),
+		trackerEvent("ROUND_OVER", `{"MATCH_GUID":"`+trackerMatchGUID+`","ROUND":1,"TEAM_WON":"RED","TIME":21,"WARMUP":false}`),
+		// World death costs bob a frag
+		trackerEvent("PLAYER_DEATH", `{"MATCH_GUID":"`+trackerMatchGUID+`","TIME":40,"ROUND":2,"WARMUP":false,"SUICIDE":true,"TEAMKILL":false,
+			"KILLER":null,"VICTIM":{"NAME":"bob","STEAM_ID":"2","TEAM":2}}`),
+		trackerEvent("PLAYER_SWITCHTEAM", `{"MATCH_GUID":"`+trackerMatchGUID+`","TIME":45,"WARMUP":false,
+			"KILLER":{"NAME":"carol","STEAM_ID":"3","OLD_TEAM":"SPECTATOR","TEAM":"BLUE"}}`),
+		trackerEvent("PLAYER_DISCONNECT", `{"MATCH_GUID":"`+trackerMatchGUID+`","NAME":"bob","STEAM_ID":"2","TIME":50,"WARMUP":false}`),
+	}
+	for _, e := range events {
+		tracker.ConsumeEvent(e)
+	}
+
+	match, ok := tracker.Match(trackerMatchGUID)
+	if !ok {
+		t.Fatal("Expected match to be tracked")
+	}
+	if !match.Started || match.GameType != "CA" || match.Map != "campgrounds" || match.Server != "ca" {
+		t.Errorf("Unexpected match info: %+v", match)
+	}
+	if match.Round != 2 || match.Elapsed != 50 {
+		t.Errorf("Expected round 2 at 50s, got round %d at %ds", match.Round, match.Elapsed)
+	}
+	if match.RoundsWon[qlstats.TeamRed] != 1 {
+		t.Errorf("Expected red to have won 1 round, got %v", match.RoundsWon)
+	}
+
+	alice, bob, carol := match.Players["1"], match.Players["2"], match.Players["3"]
+	if alice == nil || bob == nil || carol == nil {
+		t.Fatalf("Expected 3 players, got %v", match.Players)
+	}
+	if alice.Frags != 1 || alice.Deaths != 0 {
+		t.Errorf("Expected alice 1/0 (warmup frag ignored), got %d/%d", alice.Frags, alice.Deaths)
+	}
+	if bob.Frags != -1 || bob.Deaths != 2 || bob.Connected {
+		t.Errorf("Expected bob -1/2 and disconnected, got %d/%d connected=%v", bob.Frags, bob.Deaths, bob.Connected)
+	}
+	if carol.Team != qlstats.TeamBlue || carol.Name != "carol" {
+		t.Errorf("Expected carol on blue, got %+v", carol)
+	}
+
+	// The returned state is a copy
+	alice.Frags = 100
+	if match, _ := tracker.Match(trackerMatchGUID); match.Players["1"].Frags != 1 {
+		t.Error("Expected returned match state to be a copy")
+	}
+
+	// MATCH_REPORT ends the match
+	tracker.ConsumeEvent(trackerEvent("MATCH_REPORT", `{"MATCH_GUID":"`+trackerMatchGUID+`","TSCORE0":1,"TSCORE1":0}`))
+	if _, ok := tracker.Match(trackerMatchGUID); ok {
+		t.Error("Expected match to be removed after MATCH_REPORT")
+	}
+	if finished := tracker.GetMetrics()["matches_finished"]; finished != int64(1) {
+		t.Errorf("Expected 1 finished match, got %v", finished)
+	}
+}
+
+func TestMatchTrackerEvictsAbandonedMatches(t *testing.T) {
+	now := time.Now()
+	tracker := NewMatchTracker(10 * time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	tracker.ConsumeEvent(trackerEvent("MATCH_STARTED", `{"MATCH_GUID":"abandoned","GAME_TYPE":"FFA","PLAYERS":[]}`))
+	now = now.Add(5 * time.Minute)
+	tracker.ConsumeEvent(trackerEvent("MATCH_STARTED", `{"MATCH_GUID":"active","GAME_TYPE":"FFA","PLAYERS":[]}`))
+
+	matches := tracker.Matches("")
+	if len(matches) != 2 || matches[0].MatchGUID != "abandoned" {
+		t.Fatalf("Expected 2 matches ordered by start, got %+v", matches)
+	}
+	if len(tracker.Matches("duel")) != 0 {
+		t.Error("Expected no matches for another server")
+	}
+
+	// The first match goes idle for longer than the timeout
+	now = now.Add(6 * time.Minute)
+	if _, ok := tracker.Match("abandoned"); ok {
+		t.Error("Expected idle match to be hidden from queries")
+	}
+
+	tracker.ConsumeEvent(trackerEvent("PLAYER_CONNECT", `{"MATCH_GUID":"active","NAME":"alice","STEAM_ID":"1","TIME":60}`))
+	matches = tracker.Matches("ca")
+	if len(matches) != 1 || matches[0].MatchGUID != "active" {
+		t.Errorf("Expected only the active match, got %+v", matches)
+	}
+	if evicted := tracker.GetMetrics()["matches_evicted"]; evicted != int64(1) {
+		t.Errorf("Expected 1 evicted match, got %v", evicted)
+	}
+}
+
+func TestEventProcessorSweepsQuietMatches(t *testing.T) {
+	processor := NewEventProcessor(Config{BatchSize: 100, FlushIntervalSec: 30}, nil)
+	processor.sweepEvery = 10 * time.Millisecond
+
+	now := time.Now()
+	var mu sync.Mutex
+	tracker := NewMatchTracker(10 * time.Minute)
+	tracker.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	tracker.ConsumeEvent(trackerEvent("MATCH_STARTED", `{"MATCH_GUID":"abandoned","GAME_TYPE":"FFA","PLAYERS":[]}`))
+	processor.AddConsumer(tracker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go processor.Process(ctx)
+
+	// No more events arrive, the match is evicted by the periodic sweep
+	mu.Lock()
+	now = now.Add(11 * time.Minute)
+	mu.Unlock()
+
+	deadline := time.After(time.Second)
+	for tracker.GetMetrics()["matches_evicted"] != int64(1) {
+		select {
+		case <-deadline:
+			t.Fatal("Timed out waiting for the idle match to be evicted")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestEventProcessorFeedsConsumers(t *testing.T) {
+	config := Config{
+		BatchSize:        100,
+		FlushIntervalSec: 30,
+	}
+
+	processor := NewEventProcessor(config, nil)
+	tracker := NewMatchTracker(time.Minute)
+	processor.AddConsumer(tracker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go processor.Process(ctx)
+
+	processor.Submit(trackerEvent("MATCH_STARTED", `{"MATCH_GUID":"`+trackerMatchGUID+`","GAME_TYPE":"FFA","PLAYERS":[]}`))
+
+	deadline := time.After(time.Second)
+	for {
+		if _, ok := tracker.Match(trackerMatchGUID); ok {
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatal("Timed out waiting for the tracker to receive the event")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
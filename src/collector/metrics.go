@@ -0,0 +1,133 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricNamespace prefixes every exported metric name
+const metricNamespace = "quake_stats"
+
+// defaultLatencyBuckets are histogram buckets in seconds for storage latency
+var defaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Registry is a Prometheus registry for the collector metrics. Every metric
+// is registered under metricNamespace, next to the Go runtime and process
+// metrics.
+type Registry struct {
+	*prometheus.Registry
+}
+
+// NewRegistry creates a registry with the Go runtime and process collectors
+func NewRegistry() *Registry {
+	r := prometheus.NewRegistry()
+	r.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return &Registry{Registry: r}
+}
+
+// NewCounterVec registers a counter with the given label names
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *prometheus.CounterVec {
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricNamespace,
+		Name:      name,
+		Help:      help,
+	}, labels)
+	r.MustRegister(c)
+	return c
+}
+
+// NewHistogramVec registers a histogram with the given buckets and label names
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+	h := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricNamespace,
+		Name:      name,
+		Help:      help,
+		Buckets:   buckets,
+	}, labels)
+	r.MustRegister(h)
+	return h
+}
+
+// NewHistogram registers a histogram without labels
+func (r *Registry) NewHistogram(name, help string, buckets []float64) prometheus.Histogram {
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricNamespace,
+		Name:      name,
+		Help:      help,
+		Buckets:   buckets,
+	})
+	r.MustRegister(h)
+	return h
+}
+
+// GaugeSample is a single gauge value with its label values
+type GaugeSample struct {
+	Labels []string
+	Value  float64
+}
+
+// sampleCollector is a gauge or counter whose samples are read when metrics
+// are scraped, for values other components already keep track of
+type sampleCollector struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	fn        func() []GaugeSample
+}
+
+// Describe implements the prometheus.Collector interface
+func (c *sampleCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements the prometheus.Collector interface
+func (c *sampleCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, sample := range c.fn() {
+		metric, err := prometheus.NewConstMetric(c.desc, c.valueType, sample.Value, sample.Labels...)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(c.desc, err)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+// newSampleCollector registers a collector returning the samples of fn
+func (r *Registry) newSampleCollector(name, help string, valueType prometheus.ValueType, fn func() []GaugeSample, labels []string) {
+	r.MustRegister(&sampleCollector{
+		desc:      prometheus.NewDesc(prometheus.BuildFQName(metricNamespace, "", name), help, labels, nil),
+		valueType: valueType,
+		fn:        fn,
+	})
+}
+
+// NewGaugeFunc registers a gauge whose samples are returned by fn at scrape time
+func (r *Registry) NewGaugeFunc(name, help string, fn func() []GaugeSample, labels ...string) {
+	r.newSampleCollector(name, help, prometheus.GaugeValue, fn, labels)
+}
+
+// NewCounterFunc registers a counter whose samples are returned by fn at scrape time
+func (r *Registry) NewCounterFunc(name, help string, fn func() []GaugeSample, labels ...string) {
+	r.newSampleCollector(name, help, prometheus.CounterValue, fn, labels)
+}
+
+// ServeHTTP implements http.Handler for the /metrics endpoint
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	promhttp.HandlerFor(r.Registry, promhttp.HandlerOpts{}).ServeHTTP(w, req)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape renders the registry and returns the text exposition
+func scrape(t *testing.T, r *Registry) string {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := recorder.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	return recorder.Body.String()
+}
+
+// expectLines fails the test for every line missing from the exposition
+func expectLines(t *testing.T, text string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("Expected line %q in:\n%s", line, text)
+		}
+	}
+}
+
+func TestRegistryTextFormat(t *testing.T) {
+	r := NewRegistry()
+	counter := r.NewCounterVec("things_total", "Things seen.", "server")
+	counter.WithLabelValues("ca").Inc()
+	counter.WithLabelValues("ca").Add(2)
+
+	histogram := r.NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1})
+	histogram.Observe(0.05)
+	histogram.Observe(3)
+
+	r.NewGaugeFunc("depth", "Depth.", func() []GaugeSample {
+		return []GaugeSample{{Value: 42}}
+	})
+	r.NewCounterFunc("dropped_total", "Dropped.", func() []GaugeSample {
+		return []GaugeSample{{Labels: []string{"warmup"}, Value: 2}, {Labels: []string{"bots"}, Value: 1}}
+	}, "rule")
+
+	expectLines(t, scrape(t, r),
+		"# HELP quake_stats_things_total Things seen.",
+		"# TYPE quake_stats_things_total counter",
+		`quake_stats_things_total{server="ca"} 3`,
+		"# TYPE quake_stats_latency_seconds histogram",
+		`quake_stats_latency_seconds_bucket{le="1"} 1`,
+		"quake_stats_latency_seconds_count 2",
+		"# TYPE quake_stats_depth gauge",
+		"quake_stats_depth 42",
+		"# TYPE quake_stats_dropped_total counter",
+		`quake_stats_dropped_total{rule="bots"} 1`,
+		`quake_stats_dropped_total{rule="warmup"} 2`,
+		"# TYPE go_goroutines gauge",
+	)
+}
+
+func TestRegistryDuplicateMetricPanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("things_total", "Things seen.")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a duplicate metric to panic")
+		}
+	}()
+	r.NewCounterVec("things_total", "Things seen.")
+}
+
+func TestCollectorMetricsPipeline(t *testing.T) {
+	metrics := NewCollectorMetrics()
+
+	failing := metrics.InstrumentSink("postgres", &mockDBClient{storeEventsFunc: func(events []Event) error {
+		return errors.New("connection refused")
+	}})
+	working := metrics.InstrumentSink("file_backup", &mockDBClient{})
+
+	validator := NewEventValidator(&mockEventProcessor{}, nil)
+	validator.SetMetrics(metrics)
+	validator.ProcessEvent(Event{Type: "ROUND_OVER", Server: "ca", Data: json.RawMessage(`{"ROUND":1}`)})
+	validator.ProcessEvent(Event{Type: "PLAYER_DANCE", Server: "ca", Data: json.RawMessage(`{}`)})
+	validator.Reject([]byte(`{`), Event{Server: "ca"}, RejectMalformedJSON, errors.New("unexpected end of JSON input"))
+
+	metrics.ConsumeEvent(Event{Type: "ROUND_OVER", Server: "ca"})
+
+	events := []Event{{Type: "ROUND_OVER"}, {Type: "ROUND_OVER"}}
+	if err := failing.StoreEvents(events); err == nil {
+		t.Error("Expected the instrumented client to return the sink error")
+	}
+	if err := working.StoreEvents(events); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	expectLines(t, scrape(t, metrics.Registry),
+		`quake_stats_events_received_total{server="ca"} 3`,
+		`quake_stats_events_decoded_total{server="ca"} 1`,
+		`quake_stats_events_failed_total{reason="malformed_json",server="ca"} 1`,
+		`quake_stats_events_failed_total{reason="unknown_type",server="ca"} 1`,
+		`quake_stats_events_processed_total{server="ca",type="ROUND_OVER"} 1`,
+		`quake_stats_sink_writes_total{result="error",sink="postgres"} 1`,
+		`quake_stats_sink_writes_total{result="success",sink="file_backup"} 1`,
+		`quake_stats_sink_events_total{sink="file_backup"} 2`,
+		`quake_stats_sink_write_duration_seconds_count{sink="postgres"} 1`,
+	)
+}
+
+func TestEventProcessorMetrics(t *testing.T) {
+	flushed := make(chan struct{})
+	processor := NewEventProcessor(Config{BatchSize: 2, FlushIntervalSec: 30, DedupeWindowSize: 10}, &mockDBClient{
+		storeEventsFunc: func(events []Event) error {
+			close(flushed)
+			return nil
+		},
+	})
+	metrics := NewCollectorMetrics()
+	processor.SetMetrics(metrics)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go processor.Process(ctx)
+
+	processor.Submit(Event{Type: "PLAYER_KILL", Server: "ca", Data: json.RawMessage(`{"TIME":1}`)})
+	processor.Submit(Event{Type: "PLAYER_KILL", Server: "ca", Data: json.RawMessage(`{"TIME":2}`)})
+
+	select {
+	case <-flushed:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for flush")
+	}
+
+	// The flush is observed after the store returns
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(scrape(t, metrics.Registry), "quake_stats_flush_duration_seconds_count 1\n") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	expectLines(t, scrape(t, metrics.Registry),
+		`quake_stats_events_processed_total{server="ca",type="PLAYER_KILL"} 2`,
+		"quake_stats_flush_duration_seconds_count 1",
+		"quake_stats_processor_buffer_depth 0",
+		"quake_stats_processor_channel_capacity 1000",
+		"quake_stats_dedupe_dropped_total 0",
+	)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// migrationFiles holds the versioned schema migrations of the relational sink
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the advisory lock that serializes migrations when
+// several collectors start at the same time
+const migrationLockKey = 0x71737461747301
+
+// migration is a single versioned schema change
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations reads the migrations in the given directory. Files are
+// named NNNN_description.sql and applied in version order.
+func loadMigrations(fsys fs.FS, dir string) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var migrations []migration
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name %s: expected NNNN_description.sql", entry.Name())
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, other, entry.Name())
+		}
+		seen[version] = entry.Name()
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: string(data)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
+
+// runMigrations applies pending migrations to the given schema in a single
+// transaction and returns the number of migrations applied
+func runMigrations(db *sql.DB, schema string, migrations []migration) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Will be no-op if transaction is committed
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", migrationLockKey); err != nil {
+		return 0, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	quoted := pq.QuoteIdentifier(schema)
+	setup := []string{
+		"CREATE SCHEMA IF NOT EXISTS " + quoted,
+		"SET LOCAL search_path TO " + quoted,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`,
+	}
+	for _, stmt := range setup {
+		if _, err := tx.Exec(stmt); err != nil {
+			return 0, fmt.Errorf("failed to prepare schema %s: %w", schema, err)
+		}
+	}
+
+	var current int
+	if err := tx.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if _, err := tx.Exec(m.sql); err != nil {
+			return 0, fmt.Errorf("failed to apply migration %s: %w", m.name, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.version, m.name); err != nil {
+			return 0, fmt.Errorf("failed to record migration %s: %w", m.name, err)
+		}
+		log.Printf("Applied migration %s to schema %s", m.name, schema)
+		applied++
+	}
+
+	if len(migrations) > 0 && current > migrations[len(migrations)-1].version {
+		log.Printf("Warning: Schema %s is at version %d, newer than this collector (%d)",
+			schema, current, migrations[len(migrations)-1].version)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit migrations: %w", err)
+	}
+	return applied, nil
+}
@@ -0,0 +1,106 @@
+-- Normalized match tables written by the relational Postgres sink
+
+CREATE TABLE matches (
+    match_guid      TEXT PRIMARY KEY,
+    server          TEXT,
+    game_type       TEXT,
+    factory         TEXT,
+    map             TEXT,
+    server_title    TEXT,
+    frag_limit      INTEGER,
+    time_limit      INTEGER,
+    round_limit     INTEGER,
+    started_at      TIMESTAMPTZ,
+    reported_at     TIMESTAMPTZ,
+    game_length     INTEGER,
+    exit_msg        TEXT,
+    aborted         BOOLEAN,
+    team_score_red  INTEGER,
+    team_score_blue INTEGER,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ix_matches_started_at ON matches (started_at);
+
+CREATE TABLE match_players (
+    match_guid   TEXT NOT NULL REFERENCES matches (match_guid) ON DELETE CASCADE,
+    steam_id     TEXT NOT NULL,
+    name         TEXT,
+    team         INTEGER,
+    score        INTEGER,
+    kills        INTEGER,
+    deaths       INTEGER,
+    damage_dealt INTEGER,
+    damage_taken INTEGER,
+    rank         INTEGER,
+    play_time    INTEGER,
+    win          BOOLEAN,
+    quit         BOOLEAN,
+    aborted      BOOLEAN,
+    PRIMARY KEY (match_guid, steam_id)
+);
+
+CREATE INDEX ix_match_players_steam_id ON match_players (steam_id);
+
+CREATE TABLE kills (
+    id              BIGSERIAL PRIMARY KEY,
+    match_guid      TEXT NOT NULL REFERENCES matches (match_guid) ON DELETE CASCADE,
+    event_id        TEXT,
+    time            INTEGER NOT NULL,
+    round           INTEGER,
+    mod             TEXT,
+    warmup          BOOLEAN NOT NULL,
+    suicide         BOOLEAN NOT NULL,
+    team_kill       BOOLEAN NOT NULL,
+    killer_steam_id TEXT,
+    killer_name     TEXT,
+    killer_team     INTEGER,
+    killer_weapon   TEXT,
+    killer_x        DOUBLE PRECISION,
+    killer_y        DOUBLE PRECISION,
+    killer_z        DOUBLE PRECISION,
+    victim_steam_id TEXT NOT NULL,
+    victim_name     TEXT,
+    victim_team     INTEGER,
+    victim_weapon   TEXT,
+    victim_x        DOUBLE PRECISION,
+    victim_y        DOUBLE PRECISION,
+    victim_z        DOUBLE PRECISION,
+    received_at     TIMESTAMPTZ
+);
+
+CREATE INDEX ix_kills_match_guid ON kills (match_guid);
+CREATE INDEX ix_kills_killer_steam_id ON kills (killer_steam_id);
+CREATE INDEX ix_kills_victim_steam_id ON kills (victim_steam_id);
+
+CREATE TABLE player_weapon_stats (
+    match_guid      TEXT NOT NULL,
+    steam_id        TEXT NOT NULL,
+    weapon          TEXT NOT NULL,
+    kills           INTEGER NOT NULL,
+    deaths          INTEGER NOT NULL,
+    damage_given    INTEGER NOT NULL,
+    damage_received INTEGER NOT NULL,
+    hits            INTEGER NOT NULL,
+    shots           INTEGER NOT NULL,
+    pickups         INTEGER NOT NULL,
+    time_held       INTEGER NOT NULL,
+    PRIMARY KEY (match_guid, steam_id, weapon),
+    FOREIGN KEY (match_guid, steam_id) REFERENCES match_players (match_guid, steam_id) ON DELETE CASCADE
+);
+
+CREATE TABLE medals (
+    id          BIGSERIAL PRIMARY KEY,
+    match_guid  TEXT NOT NULL REFERENCES matches (match_guid) ON DELETE CASCADE,
+    event_id    TEXT,
+    steam_id    TEXT NOT NULL,
+    name        TEXT,
+    medal       TEXT NOT NULL,
+    time        INTEGER NOT NULL,
+    total       INTEGER NOT NULL,
+    warmup      BOOLEAN NOT NULL,
+    received_at TIMESTAMPTZ
+);
+
+CREATE INDEX ix_medals_match_guid ON medals (match_guid);
+CREATE INDEX ix_medals_steam_id ON medals (steam_id);
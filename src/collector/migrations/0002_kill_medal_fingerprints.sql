@@ -0,0 +1,8 @@
+-- Skip kills and medals that were already stored, e.g. when a backup is
+-- imported twice
+
+ALTER TABLE kills ADD COLUMN fingerprint TEXT;
+CREATE UNIQUE INDEX ix_kills_fingerprint ON kills (fingerprint);
+
+ALTER TABLE medals ADD COLUMN fingerprint TEXT;
+CREATE UNIQUE INDEX ix_medals_fingerprint ON medals (fingerprint);
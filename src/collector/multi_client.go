@@ -1,99 +1,676 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"sync"
+	"time"
 )
 
-// MultiDBClient implements the DBClient interface and stores events in multiple storage backends
+// SinkQueueConfig configures the queue and writes of one sink in a MultiDBClient.
+// Zero values fall back to the defaults.
+type SinkQueueConfig struct {
+	QueueSize       int `mapstructure:"queue_size"`        // Batches waiting before new ones are dropped
+	BatchSize       int `mapstructure:"batch_size"`        // Queued batches are combined up to this many events per write
+	FlushIntervalMs int `mapstructure:"flush_interval_ms"` // How long to wait for more batches to combine (0 writes right away)
+	TimeoutMs       int `mapstructure:"timeout_ms"`        // How long a write may take before it is reported as failed
+}
+
+// withDefaults fills zero values from the defaults
+func (c SinkQueueConfig) withDefaults(defaults SinkQueueConfig) SinkQueueConfig {
+	if c.QueueSize <= 0 {
+		c.QueueSize = defaults.QueueSize
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaults.BatchSize
+	}
+	if c.FlushIntervalMs <= 0 {
+		c.FlushIntervalMs = defaults.FlushIntervalMs
+	}
+	if c.TimeoutMs <= 0 {
+		c.TimeoutMs = defaults.TimeoutMs
+	}
+	return c
+}
+
+// Write policies deciding when a batch counts as stored
+const (
+	WritePolicyAll             = "all"              // Every sink must store the batch
+	WritePolicyQuorum          = "quorum"           // A majority of sinks must store the batch
+	WritePolicyAny             = "any"              // At least one sink must store the batch
+	WritePolicyAsync           = "async"            // The batch is queued for the sinks without waiting
+	WritePolicyPrimaryFallback = "primary-fallback" // The other sinks are only written when the primary fails
+)
+
+// MultiClientConfig contains configuration for the multi-client queues
+type MultiClientConfig struct {
+	Defaults          SinkQueueConfig
+	Sinks             map[string]SinkQueueConfig // Overrides by sink name
+	ShutdownTimeout   time.Duration              // Deadline for draining the queues on Close
+	WritePolicy       string
+	Primary           string        // Primary sink name for primary-fallback, the first sink when empty
+	ReconcilePath     string        // Journal of batches written to the fallback sinks
+	ReconcileInterval time.Duration // How often the journal is replayed into the primary
+}
+
+// defaultSinkQueueConfig is used for settings missing from the configuration
+var defaultSinkQueueConfig = SinkQueueConfig{
+	QueueSize: 100,
+	BatchSize: 1000,
+	TimeoutMs: 30000,
+}
+
+// defaultShutdownTimeout is used when no shutdown timeout is configured
+const defaultShutdownTimeout = 10 * time.Second
+
+// errQueueFull is reported for a sink whose queue had no room for a batch
+var errQueueFull = errors.New("queue is full")
+
+// MultiDBClient implements the DBClient interface and stores events in multiple
+// storage backends. Each backend has its own bounded queue and worker, so a
+// slow backend does not hold up the others.
 type MultiDBClient struct {
-	clients []DBClient
+	sinks           []*sinkWorker
+	shutdownTimeout time.Duration
+	policy          string
+	mu              sync.RWMutex // Guards closed against StoreEvents sending on closed queues
+	closed          bool
+
+	// primary-fallback state
+	primary         *sinkWorker
+	fallbacks       []*sinkWorker
+	journal         *Spool
+	reconcile       chan struct{} // Requests a reconciliation
+	stop            chan struct{} // Stops the reconciler
+	stopOnce        sync.Once
+	stopped         sync.WaitGroup
+	fallbackBatches int64
+	reconcileErrors int64
+	statsMu         sync.Mutex
+}
+
+// namedSink is implemented by storage clients that know their sink name
+type namedSink interface {
+	SinkName() string
 }
 
 // NewMultiDBClient creates a new multi-client for storing events in multiple backends
-func NewMultiDBClient(clients []DBClient) DBClient {
-	return &MultiDBClient{
-		clients: clients,
+func NewMultiDBClient(clients []DBClient, cfg MultiClientConfig) DBClient {
+	defaults := cfg.Defaults.withDefaults(defaultSinkQueueConfig)
+	m := &MultiDBClient{shutdownTimeout: cfg.ShutdownTimeout, policy: cfg.WritePolicy}
+	if m.shutdownTimeout <= 0 {
+		m.shutdownTimeout = defaultShutdownTimeout
+	}
+
+	switch m.policy {
+	case WritePolicyAll, WritePolicyQuorum, WritePolicyAny, WritePolicyAsync, WritePolicyPrimaryFallback:
+	case "":
+		m.policy = WritePolicyAny
+	default:
+		log.Printf("Warning: Unknown write policy %q, using %s", m.policy, WritePolicyAny)
+		m.policy = WritePolicyAny
+	}
+
+	for i, client := range clients {
+		name := fmt.Sprintf("client_%d", i)
+		if named, ok := client.(namedSink); ok {
+			name = named.SinkName()
+		}
+		sink := newSinkWorker(name, client, cfg.Sinks[name].withDefaults(defaults))
+		m.sinks = append(m.sinks, sink)
+		go sink.run()
+	}
+
+	if m.policy == WritePolicyPrimaryFallback && len(m.sinks) > 0 {
+		m.startPrimaryFallback(cfg)
+	}
+	return m
+}
+
+// startPrimaryFallback picks the primary sink, opens the reconciliation
+// journal and starts the reconciler
+func (m *MultiDBClient) startPrimaryFallback(cfg MultiClientConfig) {
+	m.primary = m.sinks[0]
+	for _, sink := range m.sinks {
+		if sink.name == cfg.Primary {
+			m.primary = sink
+		}
+	}
+	if cfg.Primary != "" && m.primary.name != cfg.Primary {
+		log.Printf("Warning: Unknown primary sink %q, using %s", cfg.Primary, m.primary.name)
+	}
+	for _, sink := range m.sinks {
+		if sink != m.primary {
+			m.fallbacks = append(m.fallbacks, sink)
+		}
 	}
+
+	journal, err := NewSpool(SpoolConfig{Path: cfg.ReconcilePath})
+	if err != nil {
+		log.Printf("Warning: Failed to open reconciliation journal, fallback data will not be replayed: %v", err)
+		return
+	}
+	m.journal = journal
+
+	interval := cfg.ReconcileInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	m.reconcile = make(chan struct{}, 1)
+	m.stop = make(chan struct{})
+	m.stopped.Add(1)
+	go m.reconcileLoop(interval)
 }
 
-// StoreEvents stores events in all clients
+// StoreEvents queues events for every client and waits until the batch is
+// stored as the write policy requires. With the async policy it does not
+// wait and only fails when no client had room for the batch; write errors
+// are then reported by each client's worker.
 func (m *MultiDBClient) StoreEvents(events []Event) error {
 	if len(events) == 0 {
 		return nil
 	}
 
-	var wg sync.WaitGroup
-	errs := make([]error, len(m.clients))
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return errors.New("multi-client is closed")
+	}
 
-	// Store events in all clients in parallel
-	for i, client := range m.clients {
-		wg.Add(1)
-		go func(idx int, c DBClient) {
-			defer wg.Done()
-			if err := c.StoreEvents(events); err != nil {
-				errs[idx] = err
-				log.Printf("Error storing events in client %d: %v", idx, err)
-			}
-		}(i, client)
+	// The caller may reuse the slice once StoreEvents returns
+	events = append([]Event(nil), events...)
+
+	switch m.policy {
+	case WritePolicyAll:
+		if failed, msg := failures(m.sinks, m.storeAcked(m.sinks, events)); failed > 0 {
+			return fmt.Errorf("%d/%d storage clients failed: %s", failed, len(m.sinks), msg)
+		}
+		return nil
+	case WritePolicyQuorum:
+		failed, msg := failures(m.sinks, m.storeAcked(m.sinks, events))
+		if stored := len(m.sinks) - failed; stored <= len(m.sinks)/2 {
+			return fmt.Errorf("only %d/%d storage clients stored the batch: %s", stored, len(m.sinks), msg)
+		}
+		if failed > 0 {
+			log.Printf("Warning: %d/%d storage clients had errors: %s", failed, len(m.sinks), msg)
+		}
+		return nil
+	case WritePolicyAny:
+		return m.storeAny(events)
+	case WritePolicyPrimaryFallback:
+		return m.storePrimaryFallback(events)
+	}
+
+	accepted := 0
+	for _, sink := range m.sinks {
+		if sink.enqueue(queuedBatch{events: events}) {
+			accepted++
+		}
+	}
+
+	if accepted == 0 {
+		return fmt.Errorf("all %d storage queues are full", len(m.sinks))
+	}
+	return nil
+}
+
+// storeAcked queues events for the given sinks and waits for their results.
+// Each sink gives up on a write after its own timeout, including the wait for
+// an earlier write that timed out, so the wait is bounded when a timeout is set.
+func (m *MultiDBClient) storeAcked(sinks []*sinkWorker, events []Event) []error {
+	results := make([]chan error, len(sinks))
+	for i, sink := range sinks {
+		results[i] = make(chan error, 1)
+		sink.enqueue(queuedBatch{events: events, done: results[i]})
+	}
+
+	errs := make([]error, len(sinks))
+	for i, result := range results {
+		errs[i] = <-result
 	}
+	return errs
+}
+
+// sinkResult is the result of a write to the sink at index
+type sinkResult struct {
+	index int
+	err   error
+}
 
-	// Wait for all storage operations to complete
-	wg.Wait()
+// storeAny queues events for every sink and returns as soon as one of them
+// stored the batch. It only fails when every sink failed; the other sinks
+// keep writing in the background.
+func (m *MultiDBClient) storeAny(events []Event) error {
+	results := make(chan sinkResult, len(m.sinks))
+	for i, sink := range m.sinks {
+		done := make(chan error, 1)
+		sink.enqueue(queuedBatch{events: events, done: done})
+		go func() { results <- sinkResult{index: i, err: <-done} }()
+	}
 
-	// Check for errors
-	var errorMsgs string
-	errorCount := 0
+	errs := make([]error, len(m.sinks))
+	for range m.sinks {
+		result := <-results
+		if result.err == nil {
+			return nil
+		}
+		errs[result.index] = result.err
+	}
+	_, msg := failures(m.sinks, errs)
+	return fmt.Errorf("all %d storage clients failed: %s", len(m.sinks), msg)
+}
+
+// failures counts the failed writes and describes them
+func failures(sinks []*sinkWorker, errs []error) (int, string) {
+	failed := 0
+	var msg string
 	for i, err := range errs {
 		if err != nil {
-			errorCount++
-			errorMsgs += fmt.Sprintf("Client %d: %v; ", i, err)
+			failed++
+			msg += fmt.Sprintf("%s: %v; ", sinks[i].name, err)
 		}
 	}
+	return failed, msg
+}
 
-	// If all clients failed, return an error
-	if errorCount == len(m.clients) {
-		return fmt.Errorf("all storage clients failed: %s", errorMsgs)
+// storePrimaryFallback writes events to the primary sink, falling back to
+// the other sinks when it fails. Batches stored in a fallback are journaled
+// so they can be replayed into the primary later.
+func (m *MultiDBClient) storePrimaryFallback(events []Event) error {
+	primaryErr := m.storeAcked([]*sinkWorker{m.primary}, events)[0]
+	if primaryErr == nil {
+		// The primary is back, replay what went to the fallbacks meanwhile
+		if m.journal != nil && m.journal.Pending() > 0 {
+			select {
+			case m.reconcile <- struct{}{}:
+			default:
+			}
+		}
+		return nil
 	}
 
-	// If some clients failed but at least one succeeded, just log the errors
-	if errorCount > 0 {
-		log.Printf("Warning: %d/%d storage clients had errors: %s", 
-			errorCount, len(m.clients), errorMsgs)
+	failed, msg := failures(m.fallbacks, m.storeAcked(m.fallbacks, events))
+	if failed == len(m.fallbacks) {
+		return fmt.Errorf("primary %s failed (%v) and no fallback stored the batch: %s", m.primary.name, primaryErr, msg)
 	}
 
+	m.statsMu.Lock()
+	m.fallbackBatches++
+	m.statsMu.Unlock()
+	log.Printf("Warning: Primary %s failed, stored %d events in fallback: %v", m.primary.name, len(events), primaryErr)
+
+	if m.journal != nil {
+		if err := m.journal.Append(events); err != nil {
+			log.Printf("Error journaling fallback batch, it will not be replayed into %s: %v", m.primary.name, err)
+		}
+	}
 	return nil
 }
 
-// Close closes all clients
+// reconcileLoop replays the journal into the primary periodically and when
+// requested
+func (m *MultiDBClient) reconcileLoop(interval time.Duration) {
+	defer m.stopped.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		case <-m.reconcile:
+		}
+		m.replayJournal()
+	}
+}
+
+// replayJournal replays journaled fallback batches into the primary, oldest
+// first, stopping at the first failure or when the client is closing
+func (m *MultiDBClient) replayJournal() {
+	replayed := 0
+	for m.journal.Pending() > 0 {
+		select {
+		case <-m.stop:
+			return
+		default:
+		}
+
+		n, err := m.journal.ReplayN(primaryWriter{m}, 1)
+		replayed += n
+		if err != nil {
+			m.statsMu.Lock()
+			m.reconcileErrors++
+			m.statsMu.Unlock()
+			log.Printf("Reconciliation into %s stopped, %d batches remain: %v", m.primary.name, m.journal.Pending(), err)
+			return
+		}
+	}
+	if replayed > 0 {
+		log.Printf("Reconciled %d fallback batches into %s", replayed, m.primary.name)
+	}
+}
+
+// primaryWriter writes replayed journal batches to the primary sink
+type primaryWriter struct {
+	m *MultiDBClient
+}
+
+// StoreEvents implements the DBClient interface
+func (w primaryWriter) StoreEvents(events []Event) error {
+	w.m.mu.RLock()
+	defer w.m.mu.RUnlock()
+	if w.m.closed {
+		return errors.New("multi-client is closed")
+	}
+	return w.m.storeAcked([]*sinkWorker{w.m.primary}, events)[0]
+}
+
+// Close implements the DBClient interface
+func (w primaryWriter) Close() error {
+	return nil
+}
+
+// GetMetrics implements the DBClient interface
+func (w primaryWriter) GetMetrics() map[string]interface{} {
+	return nil
+}
+
+// Close drains every queue, waiting at most the shutdown timeout, and closes all clients
 func (m *MultiDBClient) Close() error {
+	// Stop reconciling before the queues are closed
+	if m.stop != nil {
+		m.stopOnce.Do(func() { close(m.stop) })
+		m.stopped.Wait()
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	for _, sink := range m.sinks {
+		close(sink.queue)
+	}
+	m.mu.Unlock()
+
+	deadline := time.NewTimer(m.shutdownTimeout)
+	defer deadline.Stop()
+	for _, sink := range m.sinks {
+		select {
+		case <-sink.done:
+		case <-deadline.C:
+			log.Printf("Warning: Timed out draining storage queues after %s", m.shutdownTimeout)
+			for _, s := range m.sinks {
+				if pending := len(s.queue); pending > 0 {
+					log.Printf("Warning: Abandoning %d queued batches for %s", pending, s.name)
+				}
+			}
+			return m.closeClients()
+		}
+	}
+	return m.closeClients()
+}
+
+// closeClients closes the clients of all sinks
+func (m *MultiDBClient) closeClients() error {
 	var lastErr error
-	for i, client := range m.clients {
-		if err := client.Close(); err != nil {
-			log.Printf("Error closing client %d: %v", i, err)
+	for _, sink := range m.sinks {
+		if err := sink.client.Close(); err != nil {
+			log.Printf("Error closing client %s: %v", sink.name, err)
 			lastErr = err
 		}
 	}
 	return lastErr
 }
 
+// SinkHealth implements the SinkHealthReporter interface
+func (m *MultiDBClient) SinkHealth() []SinkHealth {
+	sinks := make([]SinkHealth, len(m.sinks))
+	for i, sink := range m.sinks {
+		sinks[i] = sink.tracker.snapshot()
+	}
+	return sinks
+}
+
+// SetMetrics exposes the queue state of every sink in the given metrics
+func (m *MultiDBClient) SetMetrics(metrics *CollectorMetrics) {
+	perSink := func(value func(s *sinkWorker) float64) func() []GaugeSample {
+		return func() []GaugeSample {
+			samples := make([]GaugeSample, len(m.sinks))
+			for i, sink := range m.sinks {
+				samples[i] = GaugeSample{Labels: []string{sink.name}, Value: value(sink)}
+			}
+			return samples
+		}
+	}
+
+	metrics.Registry.NewGaugeFunc("sink_queue_length", "Batches waiting in the queue of a storage sink.",
+		perSink(func(s *sinkWorker) float64 { return float64(len(s.queue)) }), "sink")
+	metrics.Registry.NewGaugeFunc("sink_queue_capacity", "Capacity of the queue of a storage sink.",
+		perSink(func(s *sinkWorker) float64 { return float64(cap(s.queue)) }), "sink")
+	metrics.Registry.NewCounterFunc("sink_queue_dropped_total", "Events dropped because the queue of a storage sink was full.",
+		perSink(func(s *sinkWorker) float64 { return float64(s.stats().eventsDropped) }), "sink")
+	metrics.Registry.NewCounterFunc("sink_write_timeouts_total", "Writes to a storage sink that exceeded the timeout.",
+		perSink(func(s *sinkWorker) float64 { return float64(s.stats().timeouts) }), "sink")
+}
+
 // GetMetrics returns combined metrics from all clients
 func (m *MultiDBClient) GetMetrics() map[string]interface{} {
 	metrics := map[string]interface{}{
-		"client_count": len(m.clients),
+		"client_count": len(m.sinks),
+		"write_policy": m.policy,
 	}
-	
-	// Collect metrics from each client
-	for i, client := range m.clients {
-		clientMetrics := client.GetMetrics()
-		if clientMetrics != nil {
-			prefix := fmt.Sprintf("client_%d_", i)
-			for k, v := range clientMetrics {
-				metrics[prefix+k] = v
+
+	if m.policy == WritePolicyPrimaryFallback && m.primary != nil {
+		m.statsMu.Lock()
+		metrics["primary"] = m.primary.name
+		metrics["fallback_batches"] = m.fallbackBatches
+		metrics["reconcile_errors"] = m.reconcileErrors
+		m.statsMu.Unlock()
+		if m.journal != nil {
+			for k, v := range m.journal.GetMetrics() {
+				metrics["reconcile_"+k] = v
 			}
 		}
 	}
-	
+
+	// Collect metrics from each client
+	for i, sink := range m.sinks {
+		prefix := fmt.Sprintf("client_%d_", i)
+		for k, v := range sink.client.GetMetrics() {
+			metrics[prefix+k] = v
+		}
+
+		stats := sink.stats()
+		metrics[prefix+"sink"] = sink.name
+		metrics[prefix+"queue_length"] = len(sink.queue)
+		metrics[prefix+"queue_capacity"] = cap(sink.queue)
+		metrics[prefix+"batches_dropped"] = stats.batchesDropped
+		metrics[prefix+"events_dropped"] = stats.eventsDropped
+		metrics[prefix+"write_timeouts"] = stats.timeouts
+	}
+
 	return metrics
-} 
\ No newline at end of file
+}
+
+// queuedBatch is a batch waiting in a sink queue. When done is set it
+// receives the result of the write.
+type queuedBatch struct {
+	events []Event
+	done   chan<- error
+}
+
+// sinkWorkerStats are the counters of a sink worker
+type sinkWorkerStats struct {
+	batchesDropped int64
+	eventsDropped  int64
+	timeouts       int64
+}
+
+// sinkWorker writes the queued batches of one client
+type sinkWorker struct {
+	name     string
+	client   DBClient
+	config   SinkQueueConfig
+	queue    chan queuedBatch
+	done     chan struct{} // Closed when the worker has drained its queue
+	tracker  *sinkTracker
+	inflight chan error // Result of a write that timed out but has not returned yet
+	mu       sync.Mutex
+	counters sinkWorkerStats
+}
+
+// newSinkWorker creates the worker for a client
+func newSinkWorker(name string, client DBClient, config SinkQueueConfig) *sinkWorker {
+	return &sinkWorker{
+		name:    name,
+		client:  client,
+		config:  config,
+		queue:   make(chan queuedBatch, config.QueueSize),
+		done:    make(chan struct{}),
+		tracker: newSinkTracker(name),
+	}
+}
+
+// enqueue adds a batch to the queue without blocking. A full queue drops the
+// batch for this sink.
+func (w *sinkWorker) enqueue(batch queuedBatch) bool {
+	select {
+	case w.queue <- batch:
+		return true
+	default:
+	}
+
+	w.mu.Lock()
+	w.counters.batchesDropped++
+	w.counters.eventsDropped += int64(len(batch.events))
+	w.mu.Unlock()
+	w.tracker.record(errQueueFull)
+	log.Printf("Error storing events in %s: %v, dropping %d events", w.name, errQueueFull, len(batch.events))
+	if batch.done != nil {
+		batch.done <- errQueueFull
+	}
+	return false
+}
+
+// stats returns a copy of the worker counters
+func (w *sinkWorker) stats() sinkWorkerStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.counters
+}
+
+// run writes queued batches until the queue is closed and drained
+func (w *sinkWorker) run() {
+	defer close(w.done)
+	for batch := range w.queue {
+		w.write(w.collect([]queuedBatch{batch}))
+	}
+}
+
+// collect combines further queued batches with the first one, up to the batch
+// size, waiting at most the flush interval for them to arrive
+func (w *sinkWorker) collect(batches []queuedBatch) []queuedBatch {
+	count := len(batches[0].events)
+
+	var timeout <-chan time.Time
+	if w.config.FlushIntervalMs > 0 {
+		timer := time.NewTimer(time.Duration(w.config.FlushIntervalMs) * time.Millisecond)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for count < w.config.BatchSize {
+		var next queuedBatch
+		var ok bool
+		if timeout == nil {
+			select {
+			case next, ok = <-w.queue:
+			default:
+				return batches
+			}
+		} else {
+			select {
+			case next, ok = <-w.queue:
+			case <-timeout:
+				return batches
+			}
+		}
+		if !ok {
+			return batches
+		}
+		batches = append(batches, next)
+		count += len(next.events)
+	}
+	return batches
+}
+
+// write stores the combined batches and reports the result to each of them
+func (w *sinkWorker) write(batches []queuedBatch) {
+	var events []Event
+	for _, batch := range batches {
+		events = append(events, batch.events...)
+	}
+
+	var err error
+	if len(events) > 0 {
+		err = w.store(events)
+		w.tracker.record(err)
+		if err != nil {
+			log.Printf("Error storing %d events in %s: %v", len(events), w.name, err)
+		}
+	}
+
+	for _, batch := range batches {
+		if batch.done != nil {
+			batch.done <- err
+		}
+	}
+}
+
+// store writes events, giving up after the timeout. A write that timed out
+// keeps running in the background and the next write waits for it within its
+// own timeout, so there is never more than one write in progress.
+func (w *sinkWorker) store(events []Event) error {
+	if w.config.TimeoutMs <= 0 {
+		return w.client.StoreEvents(events)
+	}
+
+	timeout := time.Duration(w.config.TimeoutMs) * time.Millisecond
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	if w.inflight != nil {
+		select {
+		case <-w.inflight:
+			w.inflight = nil
+		case <-timer.C:
+			w.timedOut()
+			return fmt.Errorf("earlier write still running after %s", timeout)
+		}
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- w.client.StoreEvents(events)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		w.inflight = result
+		w.timedOut()
+		return fmt.Errorf("write timed out after %s", timeout)
+	}
+}
+
+// timedOut counts a write that gave up after the timeout
+func (w *sinkWorker) timedOut() {
+	w.mu.Lock()
+	w.counters.timeouts++
+	w.mu.Unlock()
+}
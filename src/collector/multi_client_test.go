@@ -0,0 +1,320 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingClient blocks every write until release is closed
+type blockingClient struct {
+	recordingClient
+	release chan struct{}
+}
+
+func (b *blockingClient) StoreEvents(events []Event) error {
+	<-b.release
+	return b.recordingClient.StoreEvents(events)
+}
+
+// wait blocks until every batch queued so far has been written
+func (m *MultiDBClient) wait() {
+	m.mu.RLock()
+	if m.closed {
+		m.mu.RUnlock()
+		return
+	}
+	done := make([]chan error, len(m.sinks))
+	for i, sink := range m.sinks {
+		done[i] = make(chan error, 1)
+		sink.queue <- queuedBatch{done: done[i]}
+	}
+	m.mu.RUnlock()
+
+	for _, ch := range done {
+		<-ch
+	}
+}
+
+func TestMultiDBClientSlowSinkDoesNotBlockOthers(t *testing.T) {
+	slow := &blockingClient{release: make(chan struct{})}
+	fast := &recordingClient{}
+	client := NewMultiDBClient([]DBClient{slow, fast}, MultiClientConfig{
+		Defaults: SinkQueueConfig{QueueSize: 2},
+		Sinks:    map[string]SinkQueueConfig{"client_1": {QueueSize: 10}},
+	})
+	multi := client.(*MultiDBClient)
+
+	// Let the slow sink start writing the first batch
+	client.StoreEvents([]Event{sequencedEvent(1)})
+	for len(multi.sinks[0].queue) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for i := 2; i <= 5; i++ {
+			if err := client.StoreEvents([]Event{sequencedEvent(i)}); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("StoreEvents blocked on the slow sink")
+	}
+	waitStored(t, fast, 5)
+
+	// The slow sink holds one batch in its write and two in its queue
+	metrics := client.GetMetrics()
+	if metrics["client_0_batches_dropped"] != int64(2) || metrics["client_1_batches_dropped"] != int64(0) {
+		t.Errorf("Unexpected drop counts: %v", metrics)
+	}
+	if health := client.(SinkHealthReporter).SinkHealth(); health[0].Healthy || !health[1].Healthy {
+		t.Errorf("Expected only the slow sink to be unhealthy, got %+v", health)
+	}
+
+	close(slow.release)
+	if err := client.Close(); err != nil {
+		t.Errorf("Unexpected error closing: %v", err)
+	}
+	if stored := slow.stored(); stored != 3 {
+		t.Errorf("Expected the slow sink to drain 3 events on close, got %d", stored)
+	}
+}
+
+func TestMultiDBClientAllQueuesFull(t *testing.T) {
+	slow := &blockingClient{release: make(chan struct{})}
+	defer close(slow.release)
+	client := NewMultiDBClient([]DBClient{slow}, MultiClientConfig{
+		Defaults:        SinkQueueConfig{QueueSize: 1},
+		ShutdownTimeout: 10 * time.Millisecond,
+		WritePolicy:     WritePolicyAsync,
+	})
+	defer client.Close()
+
+	var err error
+	for i := 0; i < 5 && err == nil; i++ {
+		err = client.StoreEvents([]Event{sequencedEvent(i)})
+	}
+	if err == nil {
+		t.Error("Expected an error once every queue is full")
+	}
+}
+
+func TestMultiDBClientCombinesBatches(t *testing.T) {
+	var writes [][]Event
+	sink := &mockDBClient{storeEventsFunc: func(events []Event) error {
+		writes = append(writes, events)
+		return nil
+	}}
+	client := NewMultiDBClient([]DBClient{sink}, MultiClientConfig{
+		Defaults:    SinkQueueConfig{BatchSize: 4, FlushIntervalMs: 200},
+		WritePolicy: WritePolicyAsync,
+	})
+
+	for i := 1; i <= 6; i++ {
+		client.StoreEvents([]Event{sequencedEvent(i)})
+	}
+	client.(*MultiDBClient).wait()
+	client.Close()
+
+	if len(writes) != 2 || len(writes[0]) != 4 || len(writes[1]) != 2 {
+		t.Fatalf("Expected writes of 4 and 2 events, got %d writes", len(writes))
+	}
+	for i, e := range append(writes[0], writes[1]...) {
+		if e.Sequence != uint64(i+1) {
+			t.Errorf("Expected events in order, got %d at position %d", e.Sequence, i)
+		}
+	}
+}
+
+func TestMultiDBClientWriteTimeout(t *testing.T) {
+	slow := &blockingClient{release: make(chan struct{})}
+	client := NewMultiDBClient([]DBClient{slow}, MultiClientConfig{
+		Defaults: SinkQueueConfig{TimeoutMs: 20},
+	})
+
+	client.StoreEvents([]Event{sequencedEvent(1)})
+	client.(*MultiDBClient).wait()
+
+	health := client.(SinkHealthReporter).SinkHealth()[0]
+	if health.Healthy || health.LastError == "" {
+		t.Errorf("Expected a timed out write to be reported, got %+v", health)
+	}
+	if timeouts := client.GetMetrics()["client_0_write_timeouts"]; timeouts != int64(1) {
+		t.Errorf("Expected 1 timeout, got %v", timeouts)
+	}
+
+	close(slow.release)
+	client.Close()
+}
+
+func TestMultiDBClientWriteAfterTimeout(t *testing.T) {
+	slow := &blockingClient{release: make(chan struct{})}
+	failing := &failingClient{fail: true}
+	client := NewMultiDBClient([]DBClient{slow, failing}, MultiClientConfig{
+		Defaults:    SinkQueueConfig{TimeoutMs: 50},
+		WritePolicy: WritePolicyAll,
+	})
+	defer client.Close()
+	defer close(slow.release)
+
+	// The second batch gives up while the first write still hangs
+	for i := 1; i <= 2; i++ {
+		start := time.Now()
+		if err := client.StoreEvents([]Event{sequencedEvent(i)}); err == nil {
+			t.Errorf("Expected batch %d to fail", i)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("Batch %d took %s, expected it to give up after the timeout", i, elapsed)
+		}
+	}
+	if timeouts := client.GetMetrics()["client_0_write_timeouts"]; timeouts != int64(2) {
+		t.Errorf("Expected 2 timeouts, got %v", timeouts)
+	}
+}
+
+func TestMultiDBClientShutdownDeadline(t *testing.T) {
+	slow := &blockingClient{release: make(chan struct{})}
+	defer close(slow.release)
+	client := NewMultiDBClient([]DBClient{slow, &mockDBClient{}}, MultiClientConfig{
+		ShutdownTimeout: 50 * time.Millisecond,
+		WritePolicy:     WritePolicyAsync,
+	})
+	client.StoreEvents([]Event{sequencedEvent(1)})
+
+	start := time.Now()
+	client.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close took %s, expected it to give up after the deadline", elapsed)
+	}
+
+	if err := client.StoreEvents([]Event{sequencedEvent(2)}); err == nil || errors.Is(err, errQueueFull) {
+		t.Errorf("Expected StoreEvents to fail after Close, got %v", err)
+	}
+}
+
+// failingClient fails writes while fail is set, recording successful ones
+type failingClient struct {
+	recordingClient
+	mu   sync.Mutex
+	fail bool
+}
+
+func (f *failingClient) setFailing(fail bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fail = fail
+}
+
+func (f *failingClient) StoreEvents(events []Event) error {
+	f.mu.Lock()
+	fail := f.fail
+	f.mu.Unlock()
+	if fail {
+		return errors.New("unavailable")
+	}
+	return f.recordingClient.StoreEvents(events)
+}
+
+func TestMultiDBClientWritePolicies(t *testing.T) {
+	testCases := []struct {
+		policy  string
+		failing int // Number of failing sinks out of three
+		wantErr bool
+	}{
+		{WritePolicyAll, 0, false},
+		{WritePolicyAll, 1, true},
+		{WritePolicyQuorum, 1, false},
+		{WritePolicyQuorum, 2, true},
+		{WritePolicyAny, 2, false},
+		{WritePolicyAny, 3, true},
+		{WritePolicyAsync, 3, false},
+	}
+
+	for _, tc := range testCases {
+		clients := make([]DBClient, 3)
+		for i := range clients {
+			client := &failingClient{}
+			client.setFailing(i < tc.failing)
+			clients[i] = client
+		}
+		multi := NewMultiDBClient(clients, MultiClientConfig{WritePolicy: tc.policy})
+
+		err := multi.StoreEvents([]Event{sequencedEvent(1)})
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s with %d failing sinks: expected error %v, got %v", tc.policy, tc.failing, tc.wantErr, err)
+		}
+		multi.Close()
+	}
+}
+
+func TestMultiDBClientPrimaryFallback(t *testing.T) {
+	primary := &failingClient{}
+	fallback := &recordingClient{}
+	multi := NewMultiDBClient([]DBClient{fallback, primary}, MultiClientConfig{
+		WritePolicy:       WritePolicyPrimaryFallback,
+		Primary:           "client_1",
+		ReconcilePath:     t.TempDir(),
+		ReconcileInterval: time.Hour,
+	})
+	defer multi.Close()
+
+	// The fallback is not written while the primary works
+	if err := multi.StoreEvents([]Event{sequencedEvent(1)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if primary.stored() != 1 || fallback.stored() != 0 {
+		t.Fatalf("Expected only the primary to be written, got %d and %d", primary.stored(), fallback.stored())
+	}
+
+	primary.setFailing(true)
+	for i := 2; i <= 3; i++ {
+		if err := multi.StoreEvents([]Event{sequencedEvent(i)}); err != nil {
+			t.Fatalf("Expected the fallback to store the batch, got %v", err)
+		}
+	}
+	if fallback.stored() != 2 {
+		t.Fatalf("Expected 2 events in the fallback, got %d", fallback.stored())
+	}
+	metrics := multi.GetMetrics()
+	if metrics["fallback_batches"] != int64(2) || metrics["reconcile_pending_batches"] != 2 {
+		t.Errorf("Unexpected fallback metrics: %v", metrics)
+	}
+
+	// The next successful write triggers reconciliation of the journal
+	primary.setFailing(false)
+	if err := multi.StoreEvents([]Event{sequencedEvent(4)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	waitStored(t, &primary.recordingClient, 4)
+
+	var sequences []uint64
+	for _, e := range primary.events {
+		sequences = append(sequences, e.Sequence)
+	}
+	if sequences[0] != 1 || sequences[1] != 4 || sequences[2] != 2 || sequences[3] != 3 {
+		t.Errorf("Unexpected primary events %v", sequences)
+	}
+}
+
+func TestMultiDBClientPrimaryFallbackAllFail(t *testing.T) {
+	primary := &failingClient{}
+	primary.setFailing(true)
+	fallback := &failingClient{}
+	fallback.setFailing(true)
+	multi := NewMultiDBClient([]DBClient{primary, fallback}, MultiClientConfig{
+		WritePolicy:   WritePolicyPrimaryFallback,
+		ReconcilePath: t.TempDir(),
+	})
+	defer multi.Close()
+
+	if err := multi.StoreEvents([]Event{sequencedEvent(1)}); err == nil {
+		t.Error("Expected an error when the primary and every fallback fail")
+	}
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ImportOptions configures an import of several backup files
+type ImportOptions struct {
+	BatchSize int
+	Workers   int       // Files decoded and batches stored concurrently
+	Progress  io.Writer // Progress bar output (nil disables it)
+}
+
+// importLine is a decoded line of a backup file
+type importLine struct {
+	event *Event // nil for lines that could not be decoded
+	key   string // Ordering key of the event
+	end   int64  // Offset following the line
+	err   error  // Error that ended reading the file
+}
+
+// importEvent is an event on its way to a worker
+type importEvent struct {
+	seq   int64
+	file  int
+	event Event
+}
+
+// importOrderKey returns the key whose events must be stored in order: the
+// MATCH_GUID, or the server for events that are not part of a match
+func importOrderKey(event Event) string {
+	var fields struct {
+		MatchGUID string `json:"MATCH_GUID"`
+	}
+	if json.Unmarshal(event.Data, &fields) == nil && fields.MatchGUID != "" {
+		return fields.MatchGUID
+	}
+	return "server:" + event.Server
+}
+
+// importWorker returns the worker storing the events of an ordering key
+func importWorker(key string, workers int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(workers))
+}
+
+// commitEntry is a line of a backup file waiting to be committed
+type commitEntry struct {
+	file     int
+	offset   int64
+	event    bool // The line holds an event that must be stored first
+	complete bool // The last entry of a file
+	done     bool
+}
+
+// commitTracker commits checkpoint offsets once every line before them has
+// been stored. Workers store batches out of line order, so a file is only
+// committed up to its first line that is still in flight.
+type commitTracker struct {
+	mu         sync.Mutex
+	files      []string
+	checkpoint *ImportCheckpoint
+	events     []int // Events committed per file, including earlier runs
+	base       int64 // Sequence number of entries[0]
+	entries    []commitEntry
+	err        error // First error saving the checkpoint
+}
+
+// add appends a line in file order and returns its sequence number
+func (t *commitTracker) add(entry commitEntry) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	seq := t.base + int64(len(t.entries))
+	t.entries = append(t.entries, entry)
+	if entry.done {
+		t.advance()
+	}
+	return seq
+}
+
+// done marks stored lines and commits what can be committed
+func (t *commitTracker) done(seqs []int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, seq := range seqs {
+		t.entries[seq-t.base].done = true
+	}
+	t.advance()
+}
+
+// advance commits the leading done entries. Must be called with the lock
+// held.
+func (t *commitTracker) advance() {
+	last := make(map[int]commitEntry)
+	n := 0
+	for n < len(t.entries) && t.entries[n].done {
+		entry := t.entries[n]
+		if entry.event {
+			t.events[entry.file]++
+		}
+		last[entry.file] = entry
+		n++
+	}
+	if n == 0 {
+		return
+	}
+	t.entries = t.entries[n:]
+	t.base += int64(n)
+
+	for file, entry := range last {
+		err := t.checkpoint.Commit(t.files[file], entry.offset, t.events[file], entry.complete)
+		if err != nil && t.err == nil {
+			t.err = err
+		}
+	}
+}
+
+// countingReader counts the bytes read from a file for the progress bar
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// importProgress renders a progress bar with throughput and ETA
+type importProgress struct {
+	out        io.Writer
+	totalBytes int64
+	bytesRead  atomic.Int64
+	events     atomic.Int64
+	start      time.Time
+}
+
+// render writes the progress bar over the previous one
+func (p *importProgress) render(final bool) {
+	if p.out == nil {
+		return
+	}
+
+	read := p.bytesRead.Load()
+	events := p.events.Load()
+	elapsed := time.Since(p.start)
+	fraction := 1.0
+	if p.totalBytes > 0 && read < p.totalBytes {
+		fraction = float64(read) / float64(p.totalBytes)
+	}
+
+	const width = 30
+	filled := int(fraction * width)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", width-filled)
+	if filled < width {
+		bar = strings.Repeat("=", filled) + ">" + strings.Repeat(" ", width-filled-1)
+	}
+
+	eta := "--"
+	var eventRate, byteRate float64
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		eventRate = float64(events) / seconds
+		byteRate = float64(read) / seconds
+		if byteRate > 0 && read < p.totalBytes {
+			eta = (time.Duration(float64(p.totalBytes-read)/byteRate) * time.Second).Round(time.Second).String()
+		}
+	}
+	if final {
+		eta = elapsed.Round(time.Second).String() + " total"
+	}
+
+	fmt.Fprintf(p.out, "\r[%s] %5.1f%%  %.1f/%.1f MB  %d events  %.0f events/s  %.1f MB/s  ETA %s   ",
+		bar, fraction*100, float64(read)/(1024*1024), float64(p.totalBytes)/(1024*1024),
+		events, eventRate, byteRate/(1024*1024), eta)
+	if final {
+		fmt.Fprintln(p.out)
+	}
+}
+
+// importFiles imports the files, resuming each one from its checkpointed
+// offset. Up to Workers files are decoded ahead and batches are stored by
+// Workers workers. Events are assigned to workers by MATCH_GUID, so the
+// events of a match are stored in file order even across files. The first
+// failure stops the import; the checkpoint then holds the offsets up to
+// which every event was stored.
+func importFiles(files []string, dbClient DBClient, options ImportOptions, checkpoint *ImportCheckpoint) ImportSummary {
+	if options.BatchSize <= 0 {
+		options.BatchSize = 100
+	}
+	if options.Workers <= 0 {
+		options.Workers = 1
+	}
+
+	summary := ImportSummary{Failed: make(map[string]string)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var failOnce sync.Once
+	fail := func(file string, err error) {
+		failOnce.Do(func() {
+			summary.Failed[file] = err.Error()
+			cancel()
+		})
+	}
+
+	tracker := &commitTracker{files: files, checkpoint: checkpoint, events: make([]int, len(files))}
+	progress := &importProgress{out: options.Progress, start: time.Now()}
+
+	// Pick the files that still need to be imported
+	var pending []int
+	offsets := make([]int64, len(files))
+	for i, file := range files {
+		progressed := checkpoint.Get(file)
+		if progressed.Complete {
+			fmt.Printf("Skipping imported file: %s\n", file)
+			summary.Done++
+			continue
+		}
+		if progressed.Offset > 0 {
+			fmt.Printf("Resuming file at byte %d: %s\n", progressed.Offset, file)
+			summary.Resumed++
+		}
+		offsets[i] = progressed.Offset
+		tracker.events[i] = progressed.Events
+		if info, err := os.Stat(file); err == nil {
+			progress.totalBytes += info.Size()
+		}
+		pending = append(pending, i)
+	}
+	summary.Files = len(pending)
+
+	// Decode up to Workers files ahead of the one being dispatched
+	lines := make([]chan importLine, len(files))
+	for _, i := range pending {
+		lines[i] = make(chan importLine, 1024)
+	}
+	go func() {
+		slots := make(chan struct{}, options.Workers)
+		for _, i := range pending {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(i int) {
+				defer func() { <-slots }()
+				decodeImportFile(ctx, files[i], offsets[i], lines[i], &progress.bytesRead)
+			}(i)
+		}
+	}()
+
+	// Store batches concurrently, one queue per worker
+	queues := make([]chan importEvent, options.Workers)
+	var workers sync.WaitGroup
+	for w := range queues {
+		queues[w] = make(chan importEvent, options.BatchSize)
+		workers.Add(1)
+		go func(queue chan importEvent) {
+			defer workers.Done()
+			runImportWorker(ctx, queue, dbClient, options.BatchSize, func(batch []importEvent, err error) {
+				if err != nil {
+					fail(files[batch[0].file], fmt.Errorf("failed to store batch of %d events: %w", len(batch), err))
+					return
+				}
+				seqs := make([]int64, len(batch))
+				for i, item := range batch {
+					seqs[i] = item.seq
+				}
+				progress.events.Add(int64(len(batch)))
+				tracker.done(seqs)
+			})
+		}(queues[w])
+	}
+
+	stopProgress := make(chan struct{})
+	progressDone := make(chan struct{})
+	go func() {
+		defer close(progressDone)
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				progress.render(false)
+			case <-stopProgress:
+				return
+			}
+		}
+	}()
+
+	// Dispatch lines in file order
+	for _, i := range pending {
+		skipped, err := dispatchImportFile(ctx, i, offsets[i], lines[i], queues, tracker)
+		summary.Skipped += skipped
+		if err != nil {
+			if ctx.Err() == nil {
+				fail(files[i], err)
+			}
+			break
+		}
+	}
+
+	for _, queue := range queues {
+		close(queue)
+	}
+	workers.Wait()
+	close(stopProgress)
+	<-progressDone
+	progress.render(true)
+
+	summary.Events = int(progress.events.Load())
+	if tracker.err != nil {
+		summary.Failed[checkpoint.path] = tracker.err.Error()
+	}
+	return summary
+}
+
+// decodeImportFile sends the lines of a backup file that follow offset and
+// closes lines
+func decodeImportFile(ctx context.Context, path string, offset int64, lines chan<- importLine, bytesRead *atomic.Int64) {
+	defer close(lines)
+
+	send := func(line importLine) error {
+		select {
+		case lines <- line:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		send(importLine{err: fmt.Errorf("failed to open file %s: %w", path, err)})
+		return
+	}
+	reader, err := newBackupReader(countingReader{r: file, n: bytesRead}, file)
+	if err != nil {
+		send(importLine{err: fmt.Errorf("failed to open file %s: %w", path, err)})
+		return
+	}
+	defer reader.Close()
+
+	err = scanBackupRecords(reader, path, offset, func(event *Event, end int64) error {
+		line := importLine{event: event, end: end}
+		if event != nil {
+			line.key = importOrderKey(*event)
+		}
+		return send(line)
+	})
+	if err != nil && ctx.Err() == nil {
+		send(importLine{err: err})
+	}
+}
+
+// dispatchImportFile queues the events of a file for the workers and
+// returns the number of lines that could not be decoded
+func dispatchImportFile(ctx context.Context, file int, offset int64, lines <-chan importLine, queues []chan importEvent, tracker *commitTracker) (int, error) {
+	skipped := 0
+	for {
+		var line importLine
+		var ok bool
+		select {
+		case line, ok = <-lines:
+		case <-ctx.Done():
+			return skipped, ctx.Err()
+		}
+		if !ok {
+			break
+		}
+		if line.err != nil {
+			return skipped, line.err
+		}
+
+		offset = line.end
+		if line.event == nil {
+			skipped++
+			tracker.add(commitEntry{file: file, offset: offset, done: true})
+			continue
+		}
+
+		seq := tracker.add(commitEntry{file: file, offset: offset, event: true})
+		select {
+		case queues[importWorker(line.key, len(queues))] <- importEvent{seq: seq, file: file, event: *line.event}:
+		case <-ctx.Done():
+			return skipped, ctx.Err()
+		}
+	}
+
+	tracker.add(commitEntry{file: file, offset: offset, complete: true, done: true})
+	return skipped, nil
+}
+
+// runImportWorker stores the queued events in batches of up to batchSize,
+// reporting each batch to stored. Events still queued after the import was
+// cancelled are discarded.
+func runImportWorker(ctx context.Context, queue <-chan importEvent, dbClient DBClient, batchSize int, stored func([]importEvent, error)) {
+	batch := make([]importEvent, 0, batchSize)
+	events := make([]Event, 0, batchSize)
+
+	for item := range queue {
+		batch = append(batch, item)
+
+		// Combine whatever else is queued up to a full batch
+	combine:
+		for len(batch) < batchSize {
+			select {
+			case next, ok := <-queue:
+				if !ok {
+					break combine
+				}
+				batch = append(batch, next)
+			default:
+				break combine
+			}
+		}
+
+		if ctx.Err() == nil {
+			for _, queued := range batch {
+				events = append(events, queued.event)
+			}
+			stored(batch, dbClient.StoreEvents(events))
+		}
+		batch = batch[:0]
+		events = events[:0]
+	}
+}
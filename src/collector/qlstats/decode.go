@@ -0,0 +1,53 @@
+package qlstats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownType is returned when decoding an event type without a model
+var ErrUnknownType = errors.New("qlstats: unknown event type")
+
+// factories creates an empty model for each known event type
+var factories = map[string]func() Event{
+	TypeMatchStarted:     func() Event { return &MatchStarted{} },
+	TypeMatchReport:      func() Event { return &MatchReport{} },
+	TypePlayerConnect:    func() Event { return &PlayerConnect{} },
+	TypePlayerDisconnect: func() Event { return &PlayerDisconnect{} },
+	TypePlayerKill:       func() Event { return &PlayerKill{} },
+	TypePlayerDeath:      func() Event { return &PlayerDeath{} },
+	TypePlayerMedal:      func() Event { return &PlayerMedal{} },
+	TypePlayerStats:      func() Event { return &PlayerStats{} },
+	TypePlayerSwitchTeam: func() Event { return &PlayerSwitchTeam{} },
+	TypeRoundOver:        func() Event { return &RoundOver{} },
+}
+
+// KnownType reports whether the event type has a typed model
+func KnownType(eventType string) bool {
+	_, ok := factories[eventType]
+	return ok
+}
+
+// Types returns every event type with a typed model
+func Types() []string {
+	types := make([]string, 0, len(factories))
+	for t := range factories {
+		types = append(types, t)
+	}
+	return types
+}
+
+// Decode decodes the DATA of an event into the model for its TYPE
+func Decode(eventType string, data []byte) (Event, error) {
+	factory, ok := factories[eventType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownType, eventType)
+	}
+
+	event := factory()
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, fmt.Errorf("qlstats: failed to decode %s: %w", eventType, err)
+	}
+	return event, nil
+}
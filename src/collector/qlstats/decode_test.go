@@ -0,0 +1,136 @@
+package qlstats
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// examplesDir holds the documented Quake Live event payloads
+const examplesDir = "../../../ql/events-examples"
+
+func TestDecodeExamplesGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(examplesDir, "*.json"))
+	if err != nil {
+		t.Fatalf("Failed to list examples: %v", err)
+	}
+	if len(files) != len(factories) {
+		t.Fatalf("Expected %d example files, found %d", len(factories), len(files))
+	}
+
+	for _, file := range files {
+		eventType := strings.TrimSuffix(filepath.Base(file), ".json")
+		t.Run(eventType, func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("Failed to read example: %v", err)
+			}
+
+			event, err := Decode(eventType, data)
+			if err != nil {
+				t.Fatalf("Failed to decode example: %v", err)
+			}
+			if event.EventType() != eventType {
+				t.Errorf("Expected event type %s, got %s", eventType, event.EventType())
+			}
+
+			got, err := json.MarshalIndent(event, "", "  ")
+			if err != nil {
+				t.Fatalf("Failed to marshal event: %v", err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join("testdata", eventType+".golden.json")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatalf("Failed to update golden file: %v", err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Decoded event does not match %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestDecodeQuirks(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(examplesDir, "MATCH_REPORT.json"))
+	if err != nil {
+		t.Fatalf("Failed to read example: %v", err)
+	}
+
+	event, err := Decode(TypeMatchReport, data)
+	if err != nil {
+		t.Fatalf("Failed to decode MATCH_REPORT: %v", err)
+	}
+	report := event.(*MatchReport)
+
+	if !report.Aborted || report.Instagib {
+		t.Errorf("Expected ABORTED true and INSTAGIB 0 to decode as false, got %v and %v", report.Aborted, report.Instagib)
+	}
+	if report.FirstScorer != "" || report.LastScorer != "" {
+		t.Errorf("Expected \"none\" scorers to decode as empty, got %q and %q", report.FirstScorer, report.LastScorer)
+	}
+	if report.MatchID() != "d23d30e4-2137-4a33-b085-9db594e67d5a" {
+		t.Errorf("Unexpected match ID %s", report.MatchID())
+	}
+}
+
+func TestDecodeTeam(t *testing.T) {
+	testCases := []struct {
+		json     string
+		expected Team
+	}{
+		{`0`, TeamFree},
+		{`2`, TeamBlue},
+		{`"RED"`, TeamRed},
+		{`"SPECTATOR"`, TeamSpectator},
+		{`"blue"`, TeamBlue},
+		{`"DRAW"`, TeamDraw},
+	}
+
+	for _, tc := range testCases {
+		var team Team
+		if err := json.Unmarshal([]byte(tc.json), &team); err != nil {
+			t.Errorf("Failed to decode team %s: %v", tc.json, err)
+			continue
+		}
+		if team != tc.expected {
+			t.Errorf("Expected team %s for %s, got %s", tc.expected, tc.json, team)
+		}
+	}
+
+	var team Team
+	if err := json.Unmarshal([]byte(`"PURPLE"`), &team); err == nil {
+		t.Error("Expected error for unknown team name")
+	}
+}
+
+func TestDecodeRoundDraw(t *testing.T) {
+	event, err := Decode(TypeRoundOver, []byte(`{"MATCH_GUID":"66fe025a-63ff-4852-96bd-9102411e9fb0","ROUND":7,"TEAM_WON":"DRAW","TIME":120,"WARMUP":false}`))
+	if err != nil {
+		t.Fatalf("Failed to decode ROUND_OVER: %v", err)
+	}
+	if round := event.(*RoundOver); round.TeamWon != TeamDraw {
+		t.Errorf("Expected a draw, got %s", round.TeamWon)
+	}
+}
+
+func TestDecodeUnknownType(t *testing.T) {
+	_, err := Decode("PLAYER_DANCE", []byte(`{}`))
+	if !errors.Is(err, ErrUnknownType) {
+		t.Errorf("Expected ErrUnknownType, got %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+// Package qlstats contains typed models for the events published on the
+// Quake Live stats socket (zmq_stats_enable), and a decoder keyed on the
+// event TYPE.
+package qlstats
+
+// Event types published by Quake Live
+const (
+	TypeMatchStarted     = "MATCH_STARTED"
+	TypeMatchReport      = "MATCH_REPORT"
+	TypePlayerConnect    = "PLAYER_CONNECT"
+	TypePlayerDisconnect = "PLAYER_DISCONNECT"
+	TypePlayerKill       = "PLAYER_KILL"
+	TypePlayerDeath      = "PLAYER_DEATH"
+	TypePlayerMedal      = "PLAYER_MEDAL"
+	TypePlayerStats      = "PLAYER_STATS"
+	TypePlayerSwitchTeam = "PLAYER_SWITCHTEAM"
+	TypeRoundOver        = "ROUND_OVER"
+)
+
+// Event is implemented by every typed stats event
+type Event interface {
+	// EventType returns the TYPE the event is published with
+	EventType() string
+	// MatchID returns the MATCH_GUID of the match the event belongs to
+	MatchID() string
+}
+
+// MatchPlayer is a player listed in MATCH_STARTED
+type MatchPlayer struct {
+	Name    string `json:"NAME"`
+	SteamID string `json:"STEAM_ID"`
+	Team    Team   `json:"TEAM"`
+}
+
+// MatchStarted is published when a match leaves warmup
+type MatchStarted struct {
+	CaptureLimit int           `json:"CAPTURE_LIMIT"`
+	Factory      string        `json:"FACTORY"`
+	FactoryTitle string        `json:"FACTORY_TITLE"`
+	FragLimit    int           `json:"FRAG_LIMIT"`
+	GameType     string        `json:"GAME_TYPE"`
+	Infected     Bool          `json:"INFECTED"`
+	Instagib     Bool          `json:"INSTAGIB"`
+	Map          string        `json:"MAP"`
+	MatchGUID    string        `json:"MATCH_GUID"`
+	MercyLimit   int           `json:"MERCY_LIMIT"`
+	Players      []MatchPlayer `json:"PLAYERS"`
+	QuadHog      Bool          `json:"QUADHOG"`
+	RoundLimit   int           `json:"ROUND_LIMIT"`
+	ScoreLimit   int           `json:"SCORE_LIMIT"`
+	ServerTitle  string        `json:"SERVER_TITLE"`
+	TimeLimit    int           `json:"TIME_LIMIT"`
+	Training     Bool          `json:"TRAINING"`
+}
+
+// MatchReport is published when a match ends or is aborted
+type MatchReport struct {
+	Aborted            Bool           `json:"ABORTED"`
+	CaptureLimit       int            `json:"CAPTURE_LIMIT"`
+	ExitMsg            string         `json:"EXIT_MSG"`
+	Factory            string         `json:"FACTORY"`
+	FactoryTitle       string         `json:"FACTORY_TITLE"`
+	FirstScorer        OptionalString `json:"FIRST_SCORER"`
+	FragLimit          int            `json:"FRAG_LIMIT"`
+	GameLength         int            `json:"GAME_LENGTH"`
+	GameType           string         `json:"GAME_TYPE"`
+	Infected           Bool           `json:"INFECTED"`
+	Instagib           Bool           `json:"INSTAGIB"`
+	LastLeadChangeTime int            `json:"LAST_LEAD_CHANGE_TIME"`
+	LastScorer         OptionalString `json:"LAST_SCORER"`
+	LastTeamScorer     OptionalString `json:"LAST_TEAMSCORER"`
+	Map                string         `json:"MAP"`
+	MatchGUID          string         `json:"MATCH_GUID"`
+	MercyLimit         int            `json:"MERCY_LIMIT"`
+	QuadHog            Bool           `json:"QUADHOG"`
+	Restarted          Bool           `json:"RESTARTED"`
+	RoundLimit         int            `json:"ROUND_LIMIT"`
+	ScoreLimit         int            `json:"SCORE_LIMIT"`
+	ServerTitle        string         `json:"SERVER_TITLE"`
+	TimeLimit          int            `json:"TIME_LIMIT"`
+	Training           Bool           `json:"TRAINING"`
+	TeamScoreRed       int            `json:"TSCORE0"`
+	TeamScoreBlue      int            `json:"TSCORE1"`
+}
+
+// PlayerConnect is published when a player joins the server
+type PlayerConnect struct {
+	MatchGUID string `json:"MATCH_GUID"`
+	Name      string `json:"NAME"`
+	SteamID   string `json:"STEAM_ID"`
+	Time      int    `json:"TIME"`
+	Warmup    Bool   `json:"WARMUP"`
+}
+
+// PlayerDisconnect is published when a player leaves the server
+type PlayerDisconnect struct {
+	MatchGUID string `json:"MATCH_GUID"`
+	Name      string `json:"NAME"`
+	SteamID   string `json:"STEAM_ID"`
+	Time      int    `json:"TIME"`
+	Warmup    Bool   `json:"WARMUP"`
+}
+
+// KillParticipant is the state of the killer or victim at the time of a frag
+type KillParticipant struct {
+	Airborne  Bool     `json:"AIRBORNE"`
+	Ammo      int      `json:"AMMO"`
+	Armor     int      `json:"ARMOR"`
+	Bot       Bool     `json:"BOT"`
+	BotSkill  *float64 `json:"BOT_SKILL"`
+	Health    int      `json:"HEALTH"`
+	Holdable  *string  `json:"HOLDABLE"`
+	Name      string   `json:"NAME"`
+	Position  Vector   `json:"POSITION"`
+	Powerups  []string `json:"POWERUPS"`
+	Speed     float64  `json:"SPEED"`
+	SteamID   string   `json:"STEAM_ID"`
+	Streak    int      `json:"STREAK,omitempty"` // Only sent for the victim
+	Submerged Bool     `json:"SUBMERGED"`
+	Team      Team     `json:"TEAM"`
+	View      Vector   `json:"VIEW"`
+	Weapon    string   `json:"WEAPON"`
+}
+
+// Kill is the payload shared by PLAYER_KILL and PLAYER_DEATH. Round and
+// alive/dead counters are only sent in round-based game types.
+type Kill struct {
+	Killer         *KillParticipant `json:"KILLER"` // nil for world deaths
+	MatchGUID      string           `json:"MATCH_GUID"`
+	MOD            string           `json:"MOD"`
+	OtherTeamAlive *int             `json:"OTHER_TEAM_ALIVE"`
+	OtherTeamDead  *int             `json:"OTHER_TEAM_DEAD"`
+	Round          *int             `json:"ROUND"`
+	Suicide        Bool             `json:"SUICIDE"`
+	TeamKill       Bool             `json:"TEAMKILL"`
+	TeamAlive      *int             `json:"TEAM_ALIVE"`
+	TeamDead       *int             `json:"TEAM_DEAD"`
+	Time           int              `json:"TIME"`
+	Victim         KillParticipant  `json:"VICTIM"`
+	Warmup         Bool             `json:"WARMUP"`
+}
+
+// PlayerKill is published for every frag
+type PlayerKill struct {
+	Kill
+}
+
+// PlayerDeath is published for every death, including world deaths
+type PlayerDeath struct {
+	Kill
+}
+
+// PlayerMedal is published when a player earns a medal
+type PlayerMedal struct {
+	MatchGUID string `json:"MATCH_GUID"`
+	Medal     string `json:"MEDAL"`
+	Name      string `json:"NAME"`
+	SteamID   string `json:"STEAM_ID"`
+	Time      int    `json:"TIME"`
+	Total     int    `json:"TOTAL"`
+	Warmup    Bool   `json:"WARMUP"`
+}
+
+// Damage is the damage dealt and taken by a player
+type Damage struct {
+	Dealt int `json:"DEALT"`
+	Taken int `json:"TAKEN"`
+}
+
+// WeaponStats holds per-weapon statistics from PLAYER_STATS
+type WeaponStats struct {
+	Deaths         int `json:"D"`
+	DamageGiven    int `json:"DG"`
+	DamageReceived int `json:"DR"`
+	Hits           int `json:"H"`
+	Kills          int `json:"K"`
+	Pickups        int `json:"P"`
+	Shots          int `json:"S"`
+	TimeHeld       int `json:"T"` // Seconds
+}
+
+// PlayerStats is published for every player at the end of a match or when
+// they leave it
+type PlayerStats struct {
+	Aborted            Bool                   `json:"ABORTED"`
+	BlueFlagPickups    int                    `json:"BLUE_FLAG_PICKUPS"`
+	Damage             Damage                 `json:"DAMAGE"`
+	Deaths             int                    `json:"DEATHS"`
+	HolyShits          int                    `json:"HOLY_SHITS"`
+	Kills              int                    `json:"KILLS"`
+	Lose               Bool                   `json:"LOSE"`
+	MatchGUID          string                 `json:"MATCH_GUID"`
+	MaxStreak          int                    `json:"MAX_STREAK"`
+	Medals             map[string]int         `json:"MEDALS"`
+	Model              string                 `json:"MODEL"`
+	Name               string                 `json:"NAME"`
+	NeutralFlagPickups int                    `json:"NEUTRAL_FLAG_PICKUPS"`
+	Pickups            map[string]int         `json:"PICKUPS"`
+	PlayTime           int                    `json:"PLAY_TIME"`
+	Quit               Bool                   `json:"QUIT"`
+	Rank               int                    `json:"RANK"`
+	RedFlagPickups     int                    `json:"RED_FLAG_PICKUPS"`
+	Score              int                    `json:"SCORE"`
+	SteamID            string                 `json:"STEAM_ID"`
+	Team               Team                   `json:"TEAM"`
+	TeamJoinTime       int                    `json:"TEAM_JOIN_TIME"`
+	TeamRank           int                    `json:"TEAM_RANK"`
+	TiedRank           int                    `json:"TIED_RANK"`
+	TiedTeamRank       int                    `json:"TIED_TEAM_RANK"`
+	Warmup             Bool                   `json:"WARMUP"`
+	Weapons            map[string]WeaponStats `json:"WEAPONS"`
+	Win                Bool                   `json:"WIN"`
+}
+
+// TeamChange describes the player in PLAYER_SWITCHTEAM
+type TeamChange struct {
+	Name    string `json:"NAME"`
+	OldTeam Team   `json:"OLD_TEAM"`
+	SteamID string `json:"STEAM_ID"`
+	Team    Team   `json:"TEAM"`
+}
+
+// PlayerSwitchTeam is published when a player changes team. Quake Live
+// reports the player under the KILLER key.
+type PlayerSwitchTeam struct {
+	Player    TeamChange `json:"KILLER"`
+	MatchGUID string     `json:"MATCH_GUID"`
+	Time      int        `json:"TIME"`
+	Warmup    Bool       `json:"WARMUP"`
+}
+
+// RoundOver is published at the end of every round in round-based game types
+type RoundOver struct {
+	MatchGUID string `json:"MATCH_GUID"`
+	Round     int    `json:"ROUND"`
+	TeamWon   Team   `json:"TEAM_WON"`
+	Time      int    `json:"TIME"`
+	Warmup    Bool   `json:"WARMUP"`
+}
+
+func (e *MatchStarted) EventType() string     { return TypeMatchStarted }
+func (e *MatchReport) EventType() string      { return TypeMatchReport }
+func (e *PlayerConnect) EventType() string    { return TypePlayerConnect }
+func (e *PlayerDisconnect) EventType() string { return TypePlayerDisconnect }
+func (e *PlayerKill) EventType() string       { return TypePlayerKill }
+func (e *PlayerDeath) EventType() string      { return TypePlayerDeath }
+func (e *PlayerMedal) EventType() string      { return TypePlayerMedal }
+func (e *PlayerStats) EventType() string      { return TypePlayerStats }
+func (e *PlayerSwitchTeam) EventType() string { return TypePlayerSwitchTeam }
+func (e *RoundOver) EventType() string        { return TypeRoundOver }
+
+func (e *MatchStarted) MatchID() string     { return e.MatchGUID }
+func (e *MatchReport) MatchID() string      { return e.MatchGUID }
+func (e *PlayerConnect) MatchID() string    { return e.MatchGUID }
+func (e *PlayerDisconnect) MatchID() string { return e.MatchGUID }
+func (e *Kill) MatchID() string             { return e.MatchGUID }
+func (e *PlayerMedal) MatchID() string      { return e.MatchGUID }
+func (e *PlayerStats) MatchID() string      { return e.MatchGUID }
+func (e *PlayerSwitchTeam) MatchID() string { return e.MatchGUID }
+func (e *RoundOver) MatchID() string        { return e.MatchGUID }
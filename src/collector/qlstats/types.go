@@ -0,0 +1,144 @@
+package qlstats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Bool is a boolean that Quake Live sends either as true/false or as 0/1
+type Bool bool
+
+// UnmarshalJSON accepts true/false, numbers, numeric strings and null
+func (b *Bool) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch string(data) {
+	case "null", "false", "0", `"0"`, `"false"`, `""`:
+		*b = false
+		return nil
+	case "true", "1", `"1"`, `"true"`:
+		*b = true
+		return nil
+	}
+
+	// Any other number counts as true when non-zero
+	var n float64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("qlstats: invalid boolean %s", data)
+	}
+	*b = n != 0
+	return nil
+}
+
+// OptionalString is a string where Quake Live uses "none" (or null) for no value.
+// Missing values decode to the empty string.
+type OptionalString string
+
+// UnmarshalJSON maps "none", "null" and null to the empty string
+func (s *OptionalString) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		*s = ""
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("qlstats: invalid string %s", data)
+	}
+	if value == "none" || value == "null" {
+		value = ""
+	}
+	*s = OptionalString(value)
+	return nil
+}
+
+// Team identifies a team. Quake Live sends it as a number in most events
+// but as a name in PLAYER_SWITCHTEAM and ROUND_OVER.
+type Team int
+
+// Team values as used by Quake Live. TeamDraw is not a team: ROUND_OVER
+// sends it as TEAM_WON when no team won the round.
+const (
+	TeamDraw      Team = -1
+	TeamFree      Team = 0
+	TeamRed       Team = 1
+	TeamBlue      Team = 2
+	TeamSpectator Team = 3
+)
+
+var teamNames = map[Team]string{
+	TeamDraw:      "DRAW",
+	TeamFree:      "FREE",
+	TeamRed:       "RED",
+	TeamBlue:      "BLUE",
+	TeamSpectator: "SPECTATOR",
+}
+
+// String returns the Quake Live name of the team
+func (t Team) String() string {
+	if name, ok := teamNames[t]; ok {
+		return name
+	}
+	return strconv.Itoa(int(t))
+}
+
+// ParseTeam parses a team name or number
+func ParseTeam(value string) (Team, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	for team, name := range teamNames {
+		if name == value {
+			return team, nil
+		}
+	}
+	if value == "SPEC" {
+		return TeamSpectator, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("qlstats: unknown team %q", value)
+	}
+	return Team(n), nil
+}
+
+// UnmarshalJSON accepts a team number or name
+func (t *Team) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		*t = TeamFree
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return err
+		}
+		team, err := ParseTeam(name)
+		if err != nil {
+			return err
+		}
+		*t = team
+		return nil
+	}
+
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("qlstats: invalid team %s", data)
+	}
+	*t = Team(n)
+	return nil
+}
+
+// MarshalJSON writes the team by name
+func (t Team) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// Vector is a position or view angle
+type Vector struct {
+	X float64 `json:"X"`
+	Y float64 `json:"Y"`
+	Z float64 `json:"Z"`
+}
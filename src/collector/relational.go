@@ -0,0 +1,416 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"quake-stats/qlstats"
+)
+
+// execer is the subset of *sql.Tx used to write rows
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// RelationalPostgresClient stores events in normalized match tables
+// (matches, match_players, kills, player_weapon_stats and medals) in a
+// dedicated schema, so they do not clash with the tables owned by the API
+type RelationalPostgresClient struct {
+	db            *sql.DB
+	schema        string
+	schemaVersion int
+	mu            sync.Mutex
+	closed        bool
+	// Metrics
+	totalEventsStored int
+	eventsSkipped     int
+	rowsWritten       map[string]int
+	lastStoreTime     time.Time
+}
+
+// NewRelationalPostgresClient connects to PostgreSQL and applies pending
+// schema migrations before returning
+func NewRelationalPostgresClient(cfg Config) (DBClient, error) {
+	if !cfg.PostgresRelationalEnabled {
+		return nil, nil
+	}
+
+	schema := cfg.PostgresRelationalSchema
+	if schema == "" {
+		schema = "stats"
+	}
+
+	db, err := sql.Open("postgres", cfg.PostgresConnectionString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	maxConns := cfg.PostgresMaxConns
+	if maxConns <= 0 {
+		maxConns = 1
+	}
+	db.SetMaxOpenConns(maxConns)
+	db.SetMaxIdleConns(maxConns)
+	db.SetConnMaxIdleTime(time.Duration(cfg.PostgresIdleTimeoutMin) * time.Minute)
+
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	applied, err := runMigrations(db, schema, migrations)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	version := 0
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].version
+	}
+	log.Printf("Relational PostgreSQL storage initialized: schema %s at version %d (%d migrations applied)",
+		schema, version, applied)
+
+	return &RelationalPostgresClient{
+		db:            db,
+		schema:        schema,
+		schemaVersion: version,
+		rowsWritten:   make(map[string]int),
+	}, nil
+}
+
+// StoreEvents writes a batch of events to the match tables in one transaction
+func (r *RelationalPostgresClient) StoreEvents(events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	closed := r.closed
+	r.mu.Unlock()
+	if closed {
+		return fmt.Errorf("relational storage is closed")
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Will be no-op if transaction is committed
+
+	if _, err := tx.Exec("SET LOCAL search_path TO " + pq.QuoteIdentifier(r.schema)); err != nil {
+		return fmt.Errorf("failed to set search path: %w", err)
+	}
+
+	writer := newRelationalWriter(tx)
+	for _, event := range events {
+		if err := writer.write(event); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.mu.Lock()
+	r.totalEventsStored += len(events) - writer.skipped
+	r.eventsSkipped += writer.skipped
+	for table, rows := range writer.rows {
+		r.rowsWritten[table] += rows
+	}
+	r.lastStoreTime = time.Now()
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Close closes the database connection
+func (r *RelationalPostgresClient) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.db.Close()
+}
+
+// GetMetrics returns storage metrics
+func (r *RelationalPostgresClient) GetMetrics() map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	metrics := map[string]interface{}{
+		"schema":              r.schema,
+		"schema_version":      r.schemaVersion,
+		"total_events_stored": r.totalEventsStored,
+		"events_skipped":      r.eventsSkipped,
+		"connection_active":   !r.closed,
+	}
+	for table, rows := range r.rowsWritten {
+		metrics["rows_"+table] = rows
+	}
+	if !r.lastStoreTime.IsZero() {
+		metrics["last_store_time"] = r.lastStoreTime.Format(time.RFC3339)
+	}
+	return metrics
+}
+
+// relationalWriter maps events of one batch to rows of the match tables
+type relationalWriter struct {
+	tx      execer
+	matches map[string]bool // Matches known to have a row in this batch
+	rows    map[string]int  // Rows written per table
+	skipped int             // Events without a relational representation
+}
+
+// newRelationalWriter creates a writer for one transaction
+func newRelationalWriter(tx execer) *relationalWriter {
+	return &relationalWriter{
+		tx:      tx,
+		matches: make(map[string]bool),
+		rows:    make(map[string]int),
+	}
+}
+
+// write stores the rows of a single event. Kills are taken from PLAYER_DEATH
+// since it is published for every death, including world deaths, while
+// PLAYER_KILL would record the same frag twice.
+func (w *relationalWriter) write(e Event) error {
+	decoded, err := e.Decode()
+	if err != nil || decoded.MatchID() == "" {
+		w.skipped++
+		return nil
+	}
+
+	switch ev := decoded.(type) {
+	case *qlstats.MatchStarted:
+		return w.matchStarted(e, ev)
+	case *qlstats.MatchReport:
+		return w.matchReport(e, ev)
+	case *qlstats.PlayerStats:
+		if ev.Warmup {
+			w.skipped++
+			return nil
+		}
+		return w.playerStats(e, ev)
+	case *qlstats.PlayerDeath:
+		return w.kill(e, &ev.Kill)
+	case *qlstats.PlayerMedal:
+		return w.medal(e, ev)
+	}
+
+	w.skipped++
+	return nil
+}
+
+// exec runs a statement and counts the row against its table
+func (w *relationalWriter) exec(table, query string, args ...interface{}) error {
+	if _, err := w.tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to write %s: %w", table, err)
+	}
+	w.rows[table]++
+	return nil
+}
+
+// ensureMatch makes sure a match row exists, so events of matches whose
+// MATCH_STARTED was missed can still be stored
+func (w *relationalWriter) ensureMatch(guid, server string) error {
+	if w.matches[guid] {
+		return nil
+	}
+	if _, err := w.tx.Exec(
+		"INSERT INTO matches (match_guid, server) VALUES ($1, $2) ON CONFLICT (match_guid) DO NOTHING",
+		guid, nullString(server),
+	); err != nil {
+		return fmt.Errorf("failed to write matches: %w", err)
+	}
+	w.matches[guid] = true
+	return nil
+}
+
+// matchStarted stores the match settings and its initial players
+func (w *relationalWriter) matchStarted(e Event, ev *qlstats.MatchStarted) error {
+	err := w.exec("matches", `INSERT INTO matches
+		(match_guid, server, game_type, factory, map, server_title, frag_limit, time_limit, round_limit, started_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (match_guid) DO UPDATE SET
+			server = COALESCE(EXCLUDED.server, matches.server),
+			game_type = EXCLUDED.game_type,
+			factory = EXCLUDED.factory,
+			map = EXCLUDED.map,
+			server_title = EXCLUDED.server_title,
+			frag_limit = EXCLUDED.frag_limit,
+			time_limit = EXCLUDED.time_limit,
+			round_limit = EXCLUDED.round_limit,
+			started_at = EXCLUDED.started_at`,
+		ev.MatchGUID, nullString(e.Server), ev.GameType, ev.Factory, ev.Map, ev.ServerTitle,
+		ev.FragLimit, ev.TimeLimit, ev.RoundLimit, eventTime(e))
+	if err != nil {
+		return err
+	}
+	w.matches[ev.MatchGUID] = true
+
+	for _, player := range ev.Players {
+		err := w.exec("match_players", `INSERT INTO match_players (match_guid, steam_id, name, team)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (match_guid, steam_id) DO UPDATE SET name = EXCLUDED.name, team = EXCLUDED.team`,
+			ev.MatchGUID, player.SteamID, player.Name, int(player.Team))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchReport stores the final result of a match
+func (w *relationalWriter) matchReport(e Event, ev *qlstats.MatchReport) error {
+	err := w.exec("matches", `INSERT INTO matches
+		(match_guid, server, game_type, factory, map, server_title, frag_limit, time_limit, round_limit,
+		 reported_at, game_length, exit_msg, aborted, team_score_red, team_score_blue)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT (match_guid) DO UPDATE SET
+			server = COALESCE(matches.server, EXCLUDED.server),
+			game_type = EXCLUDED.game_type,
+			factory = EXCLUDED.factory,
+			map = EXCLUDED.map,
+			server_title = EXCLUDED.server_title,
+			frag_limit = EXCLUDED.frag_limit,
+			time_limit = EXCLUDED.time_limit,
+			round_limit = EXCLUDED.round_limit,
+			reported_at = EXCLUDED.reported_at,
+			game_length = EXCLUDED.game_length,
+			exit_msg = EXCLUDED.exit_msg,
+			aborted = EXCLUDED.aborted,
+			team_score_red = EXCLUDED.team_score_red,
+			team_score_blue = EXCLUDED.team_score_blue`,
+		ev.MatchGUID, nullString(e.Server), ev.GameType, ev.Factory, ev.Map, ev.ServerTitle,
+		ev.FragLimit, ev.TimeLimit, ev.RoundLimit, eventTime(e), ev.GameLength, ev.ExitMsg,
+		bool(ev.Aborted), ev.TeamScoreRed, ev.TeamScoreBlue)
+	if err != nil {
+		return err
+	}
+	w.matches[ev.MatchGUID] = true
+	return nil
+}
+
+// playerStats stores a player's end of match summary and weapon stats
+func (w *relationalWriter) playerStats(e Event, ev *qlstats.PlayerStats) error {
+	if err := w.ensureMatch(ev.MatchGUID, e.Server); err != nil {
+		return err
+	}
+
+	err := w.exec("match_players", `INSERT INTO match_players
+		(match_guid, steam_id, name, team, score, kills, deaths, damage_dealt, damage_taken, rank, play_time, win, quit, aborted)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (match_guid, steam_id) DO UPDATE SET
+			name = EXCLUDED.name,
+			team = EXCLUDED.team,
+			score = EXCLUDED.score,
+			kills = EXCLUDED.kills,
+			deaths = EXCLUDED.deaths,
+			damage_dealt = EXCLUDED.damage_dealt,
+			damage_taken = EXCLUDED.damage_taken,
+			rank = EXCLUDED.rank,
+			play_time = EXCLUDED.play_time,
+			win = EXCLUDED.win,
+			quit = EXCLUDED.quit,
+			aborted = EXCLUDED.aborted`,
+		ev.MatchGUID, ev.SteamID, ev.Name, int(ev.Team), ev.Score, ev.Kills, ev.Deaths,
+		ev.Damage.Dealt, ev.Damage.Taken, ev.Rank, ev.PlayTime, bool(ev.Win), bool(ev.Quit), bool(ev.Aborted))
+	if err != nil {
+		return err
+	}
+
+	for weapon, stats := range ev.Weapons {
+		err := w.exec("player_weapon_stats", `INSERT INTO player_weapon_stats
+			(match_guid, steam_id, weapon, kills, deaths, damage_given, damage_received, hits, shots, pickups, time_held)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (match_guid, steam_id, weapon) DO UPDATE SET
+				kills = EXCLUDED.kills,
+				deaths = EXCLUDED.deaths,
+				damage_given = EXCLUDED.damage_given,
+				damage_received = EXCLUDED.damage_received,
+				hits = EXCLUDED.hits,
+				shots = EXCLUDED.shots,
+				pickups = EXCLUDED.pickups,
+				time_held = EXCLUDED.time_held`,
+			ev.MatchGUID, ev.SteamID, weapon, stats.Kills, stats.Deaths, stats.DamageGiven,
+			stats.DamageReceived, stats.Hits, stats.Shots, stats.Pickups, stats.TimeHeld)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kill stores a death with its killer and victim
+func (w *relationalWriter) kill(e Event, kill *qlstats.Kill) error {
+	if err := w.ensureMatch(kill.MatchGUID, e.Server); err != nil {
+		return err
+	}
+
+	var killerSteamID, killerName, killerWeapon sql.NullString
+	var killerTeam sql.NullInt64
+	var killerX, killerY, killerZ sql.NullFloat64
+	if k := kill.Killer; k != nil {
+		killerSteamID = nullString(k.SteamID)
+		killerName = nullString(k.Name)
+		killerWeapon = nullString(k.Weapon)
+		killerTeam = sql.NullInt64{Int64: int64(k.Team), Valid: true}
+		killerX = sql.NullFloat64{Float64: k.Position.X, Valid: true}
+		killerY = sql.NullFloat64{Float64: k.Position.Y, Valid: true}
+		killerZ = sql.NullFloat64{Float64: k.Position.Z, Valid: true}
+	}
+
+	var round sql.NullInt64
+	if kill.Round != nil {
+		round = sql.NullInt64{Int64: int64(*kill.Round), Valid: true}
+	}
+
+	v := kill.Victim
+	return w.exec("kills", `INSERT INTO kills
+		(match_guid, event_id, time, round, mod, warmup, suicide, team_kill,
+		 killer_steam_id, killer_name, killer_team, killer_weapon, killer_x, killer_y, killer_z,
+		 victim_steam_id, victim_name, victim_team, victim_weapon, victim_x, victim_y, victim_z, received_at, fingerprint)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)
+		ON CONFLICT (fingerprint) DO NOTHING`,
+		kill.MatchGUID, nullString(e.ID), kill.Time, round, kill.MOD, bool(kill.Warmup), bool(kill.Suicide), bool(kill.TeamKill),
+		killerSteamID, killerName, killerTeam, killerWeapon, killerX, killerY, killerZ,
+		v.SteamID, v.Name, int(v.Team), v.Weapon, v.Position.X, v.Position.Y, v.Position.Z, eventTime(e), e.Fingerprint())
+}
+
+// medal stores a medal award
+func (w *relationalWriter) medal(e Event, ev *qlstats.PlayerMedal) error {
+	if err := w.ensureMatch(ev.MatchGUID, e.Server); err != nil {
+		return err
+	}
+
+	return w.exec("medals", `INSERT INTO medals
+		(match_guid, event_id, steam_id, name, medal, time, total, warmup, received_at, fingerprint)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (fingerprint) DO NOTHING`,
+		ev.MatchGUID, nullString(e.ID), ev.SteamID, ev.Name, ev.Medal, ev.Time, ev.Total, bool(ev.Warmup), eventTime(e),
+		e.Fingerprint())
+}
+
+// nullString maps the empty string to NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// eventTime returns the time the collector received the event, or NULL for
+// events without an envelope
+func eventTime(e Event) sql.NullTime {
+	return sql.NullTime{Time: e.ReceivedAt, Valid: !e.ReceivedAt.IsZero()}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// recordingExecer records the statements executed by the relational writer
+type recordingExecer struct {
+	queries []string
+	args    [][]interface{}
+}
+
+func (r *recordingExecer) Exec(query string, args ...interface{}) (sql.Result, error) {
+	r.queries = append(r.queries, query)
+	r.args = append(r.args, args)
+	return nil, nil
+}
+
+// tables returns the table each recorded INSERT wrote to
+func (r *recordingExecer) tables() []string {
+	var tables []string
+	for _, query := range r.queries {
+		fields := strings.Fields(query)
+		if len(fields) > 2 && fields[0] == "INSERT" {
+			tables = append(tables, fields[2])
+		}
+	}
+	return tables
+}
+
+func TestRelationalWriterMapsEventsToTables(t *testing.T) {
+	receivedAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	event := func(eventType, data string) Event {
+		return Event{ID: "id-" + eventType, Server: "ca", ReceivedAt: receivedAt, Type: eventType, Data: json.RawMessage(data)}
+	}
+
+	events := []Event{
+		event("MATCH_STARTED", `{"MATCH_GUID":"m1","GAME_TYPE":"CA","MAP":"campgrounds",
+			"PLAYERS":[{"NAME":"alice","STEAM_ID":"1","TEAM":1},{"NAME":"bob","STEAM_ID":"2","TEAM":2}]}`),
+		event("PLAYER_KILL", `{"MATCH_GUID":"m1","TIME":10,"KILLER":{"NAME":"alice","STEAM_ID":"1"},"VICTIM":{"NAME":"bob","STEAM_ID":"2"}}`),
+		event("PLAYER_DEATH", `{"MATCH_GUID":"m1","TIME":10,"ROUND":1,"MOD":"ROCKET",
+			"KILLER":{"NAME":"alice","STEAM_ID":"1","TEAM":1,"WEAPON":"ROCKET","POSITION":{"X":1,"Y":2,"Z":3}},
+			"VICTIM":{"NAME":"bob","STEAM_ID":"2","TEAM":2,"WEAPON":"SHOTGUN"}}`),
+		event("PLAYER_DEATH", `{"MATCH_GUID":"m1","TIME":12,"MOD":"FALLING","KILLER":null,"VICTIM":{"NAME":"bob","STEAM_ID":"2"}}`),
+		event("PLAYER_MEDAL", `{"MATCH_GUID":"m1","MEDAL":"FIRSTFRAG","NAME":"alice","STEAM_ID":"1","TIME":10,"TOTAL":1}`),
+		event("PLAYER_STATS", `{"MATCH_GUID":"m1","NAME":"alice","STEAM_ID":"1","TEAM":1,"KILLS":1,
+			"WEAPONS":{"ROCKET":{"K":1,"S":4,"H":2}}}`),
+		event("PLAYER_STATS", `{"MATCH_GUID":"m1","NAME":"bob","STEAM_ID":"2","WARMUP":true}`),
+		event("MATCH_REPORT", `{"MATCH_GUID":"m1","GAME_TYPE":"CA","TSCORE0":1,"TSCORE1":0}`),
+		event("PLAYER_CONNECT", `{"MATCH_GUID":"m1","NAME":"carol","STEAM_ID":"3"}`),
+	}
+
+	tx := &recordingExecer{}
+	writer := newRelationalWriter(tx)
+	for _, e := range events {
+		if err := writer.write(e); err != nil {
+			t.Fatalf("Unexpected error writing %s: %v", e.Type, err)
+		}
+	}
+
+	want := []string{
+		"matches", "match_players", "match_players", // MATCH_STARTED
+		"kills", "kills", // PLAYER_DEATH only, PLAYER_KILL is skipped
+		"medals",
+		"match_players", "player_weapon_stats",
+		"matches", // MATCH_REPORT
+	}
+	if got := tx.tables(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected writes to %v, got %v", want, got)
+	}
+
+	// PLAYER_KILL, warmup PLAYER_STATS and PLAYER_CONNECT have no rows
+	if writer.skipped != 3 {
+		t.Errorf("Expected 3 skipped events, got %d", writer.skipped)
+	}
+	if writer.rows["kills"] != 2 || writer.rows["match_players"] != 3 {
+		t.Errorf("Unexpected row counts: %v", writer.rows)
+	}
+
+	// The world death has no killer
+	worldDeath := tx.args[4]
+	if worldDeath[8].(sql.NullString).Valid || worldDeath[10].(sql.NullInt64).Valid {
+		t.Errorf("Expected NULL killer for world death, got %v", worldDeath[8:15])
+	}
+	frag := tx.args[3]
+	if frag[8].(sql.NullString).String != "1" || frag[12].(sql.NullFloat64).Float64 != 1 {
+		t.Errorf("Expected killer columns for frag, got %v", frag[8:15])
+	}
+	if frag[22].(sql.NullTime).Time != receivedAt {
+		t.Errorf("Expected received_at %v, got %v", receivedAt, frag[22])
+	}
+}
+
+func TestRelationalWriterEnsuresMatchOnce(t *testing.T) {
+	tx := &recordingExecer{}
+	writer := newRelationalWriter(tx)
+
+	// MATCH_STARTED was missed, the match row is created on demand
+	for i := 0; i < 2; i++ {
+		err := writer.write(Event{Type: "PLAYER_MEDAL", Data: json.RawMessage(`{"MATCH_GUID":"m2","MEDAL":"EXCELLENT","STEAM_ID":"1"}`)})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	want := []string{"matches", "medals", "medals"}
+	if got := tx.tables(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected writes to %v, got %v", want, got)
+	}
+	if !strings.Contains(tx.queries[0], "ON CONFLICT (match_guid) DO NOTHING") {
+		t.Errorf("Expected match row to be inserted without overwriting, got %s", tx.queries[0])
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("Failed to load embedded migrations: %v", err)
+	}
+	if len(migrations) == 0 || migrations[0].version != 1 {
+		t.Fatalf("Expected embedded migrations starting at version 1, got %+v", migrations)
+	}
+	for _, table := range []string{"matches", "match_players", "kills", "player_weapon_stats", "medals"} {
+		if !strings.Contains(migrations[0].sql, "CREATE TABLE "+table+" ") {
+			t.Errorf("Expected initial migration to create %s", table)
+		}
+	}
+
+	fsys := fstest.MapFS{
+		"m/0010_later.sql":  {Data: []byte("SELECT 10")},
+		"m/0002_second.sql": {Data: []byte("SELECT 2")},
+		"m/README.md":       {Data: []byte("ignored")},
+	}
+	migrations, err = loadMigrations(fsys, "m")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].name != "0002_second" || migrations[1].version != 10 {
+		t.Errorf("Expected migrations in version order, got %+v", migrations)
+	}
+
+	fsys["m/0002_duplicate.sql"] = &fstest.MapFile{Data: []byte("SELECT 2")}
+	if _, err := loadMigrations(fsys, "m"); err == nil {
+		t.Error("Expected error for duplicate migration version")
+	}
+
+	if _, err := loadMigrations(fstest.MapFS{"m/init.sql": {}}, "m"); err == nil {
+		t.Error("Expected error for migration without version")
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseReplaySpeed parses a replay speed: "realtime", a multiplier such as
+// "10" or "10x", or "max" for as fast as possible, which returns 0
+func ParseReplaySpeed(value string) (float64, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "realtime", "real-time", "":
+		return 1, nil
+	case "max":
+		return 0, nil
+	}
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(value), "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("invalid replay speed %q: use realtime, a multiplier such as 10x, or max", value)
+	}
+	return speed, nil
+}
+
+// Replayer publishes backed up events, paced by their stored timestamps
+type Replayer struct {
+	publisher EventPublisher
+	speed     float64       // Multiplier of the original pace (0 publishes as fast as possible)
+	maxGap    time.Duration // Longest pause between two events at the original pace (0 keeps every pause)
+	server    string        // Only replay events from this server (empty replays all)
+	eventType string        // Only replay events of this type (empty replays all)
+	wait      func(ctx context.Context, d time.Duration) error
+	now       func() time.Time
+
+	// Pacing state carried across files
+	started   bool
+	startWall time.Time
+	startTime time.Time // Stored timestamp published at startWall
+	lastTime  time.Time
+	published int
+}
+
+// NewReplayer creates a replayer publishing at the given speed
+func NewReplayer(publisher EventPublisher, speed float64, maxGap time.Duration) *Replayer {
+	return &Replayer{
+		publisher: publisher,
+		speed:     speed,
+		maxGap:    maxGap,
+		wait:      sleepContext,
+		now:       time.Now,
+	}
+}
+
+// sleepContext sleeps for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ReplayFile publishes the events of a plain or gzipped backup file
+func (r *Replayer) ReplayFile(ctx context.Context, path string) error {
+	file, err := openBackupFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	return scanBackupRecords(file, path, 0, func(event *Event, end int64) error {
+		if event == nil {
+			return nil
+		}
+		return r.Publish(ctx, *event)
+	})
+}
+
+// Publish publishes an event once it is due according to its ReceivedAt,
+// unless it is filtered out
+func (r *Replayer) Publish(ctx context.Context, event Event) error {
+	if (r.server != "" && event.Server != r.server) || (r.eventType != "" && event.Type != r.eventType) {
+		return nil
+	}
+	if err := r.pace(ctx, event.ReceivedAt); err != nil {
+		return err
+	}
+	if err := r.publisher.Publish(event); err != nil {
+		return err
+	}
+	r.published++
+	return nil
+}
+
+// pace waits until an event stored at timestamp is due. Every event is
+// scheduled relative to the first one, so publishing time does not add up
+// to drift. Events stored out of order are published right away.
+func (r *Replayer) pace(ctx context.Context, timestamp time.Time) error {
+	if r.speed <= 0 || timestamp.IsZero() {
+		return nil
+	}
+	if !r.started {
+		r.started = true
+		r.startWall = r.now()
+		r.startTime = timestamp
+		r.lastTime = timestamp
+		return nil
+	}
+
+	// Skip the part of long pauses (e.g. the collector was down) beyond the
+	// maximum gap
+	if gap := timestamp.Sub(r.lastTime); r.maxGap > 0 && gap > r.maxGap {
+		r.startTime = r.startTime.Add(gap - r.maxGap)
+	}
+	if timestamp.After(r.lastTime) {
+		r.lastTime = timestamp
+	}
+
+	due := r.startWall.Add(time.Duration(float64(timestamp.Sub(r.startTime)) / r.speed))
+	if delay := due.Sub(r.now()); delay > 0 {
+		return r.wait(ctx, delay)
+	}
+	return nil
+}
+
+// ReplayTool is a command-line tool to publish backed up events on a local
+// ZMQ PUB socket in the Quake Live wire format, so a collector, the API or
+// dashboards can consume recorded matches as if a live server sent them
+func ReplayTool() {
+	// Only run if we're specifically using the replay command
+	if len(os.Args) < 2 || os.Args[1] != "replay" {
+		return
+	}
+
+	// Configure replay flags
+	replayCmd := flag.NewFlagSet("replay", flag.ExitOnError)
+	filePath := replayCmd.String("file", "", "Path to event file to replay")
+	dirPath := replayCmd.String("dir", "", "Directory containing event files to replay")
+	endpoint := replayCmd.String("bind", "tcp://127.0.0.1:27960", "Endpoint to bind the PUB socket to")
+	password := replayCmd.String("password", "", "Require subscribers to authenticate with this ZMQ PLAIN password")
+	speedFlag := replayCmd.String("speed", "realtime", "Replay speed: realtime, a multiplier such as 10x, or max")
+	maxGap := replayCmd.Duration("max-gap", 0, "Shorten pauses between events to at most this long, e.g. 30s (0 keeps them)")
+	server := replayCmd.String("server", "", "Only replay events from this server")
+	eventType := replayCmd.String("type", "", "Only replay events of this type")
+	startDelay := replayCmd.Duration("start-delay", time.Second, "Wait this long after binding so subscribers can connect")
+	sendHWM := replayCmd.Int("send-hwm", 0, "Messages queued per subscriber before ZMQ drops them (0 keeps the default of 1000)")
+
+	// Parse replay flags (skip the "replay" arg)
+	if err := replayCmd.Parse(os.Args[2:]); err != nil {
+		log.Fatalf("Error parsing replay flags: %v", err)
+	}
+
+	// Validate required parameters
+	if *filePath == "" && *dirPath == "" {
+		log.Fatalf("Error: Either -file or -dir must be specified")
+	}
+	speed, err := ParseReplaySpeed(*speedFlag)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	files := []string{*filePath}
+	if *filePath == "" {
+		// Plain and gzipped files, sorted by name (which includes timestamp)
+		files, err = backupFiles(*dirPath)
+		if err != nil {
+			log.Fatalf("Error finding event files: %v", err)
+		}
+		if len(files) == 0 {
+			log.Fatalf("No event files found in directory %s", *dirPath)
+		}
+	}
+
+	publisher, err := NewZmqPublisher(*endpoint, *password, *sendHWM)
+	if err != nil {
+		log.Fatalf("Error creating publisher: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	setupSignalHandling(cancel)
+
+	replayer := NewReplayer(publisher, speed, *maxGap)
+	replayer.server = *server
+	replayer.eventType = *eventType
+
+	log.Printf("Publishing on %s at %s speed, waiting %s for subscribers", *endpoint, *speedFlag, *startDelay)
+	if err := sleepContext(ctx, *startDelay); err != nil {
+		publisher.Close()
+		os.Exit(0)
+	}
+
+	start := time.Now()
+	for i, file := range files {
+		fmt.Printf("[%d/%d] Replaying file: %s\n", i+1, len(files), file)
+		if err := replayer.ReplayFile(ctx, file); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			publisher.Close()
+			log.Fatalf("Error replaying file %s: %v", file, err)
+		}
+	}
+
+	fmt.Printf("Replayed %d events in %s\n", replayer.published, time.Since(start).Round(time.Millisecond))
+	publisher.Close()
+	os.Exit(0)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// recordingPublisher records published events
+type recordingPublisher struct {
+	events []Event
+}
+
+func (p *recordingPublisher) Publish(e Event) error {
+	p.events = append(p.events, e)
+	return nil
+}
+
+func TestParseReplaySpeed(t *testing.T) {
+	cases := map[string]float64{"realtime": 1, "": 1, "10x": 10, "2.5": 2.5, "MAX": 0}
+	for value, want := range cases {
+		if got, err := ParseReplaySpeed(value); err != nil || got != want {
+			t.Errorf("ParseReplaySpeed(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"fast", "0", "-2x"} {
+		if _, err := ParseReplaySpeed(value); err == nil {
+			t.Errorf("Expected an error for speed %q", value)
+		}
+	}
+}
+
+func TestReplayFileFiltersAndOrder(t *testing.T) {
+	start := time.Date(2025, 4, 20, 20, 0, 0, 0, time.UTC)
+	var events []Event
+	for i := 1; i <= 4; i++ {
+		event := sequencedEvent(i)
+		event.Server = "ca"
+		if i == 3 {
+			event.Server = "duel"
+		}
+		event.ReceivedAt = start.Add(time.Duration(i) * time.Second)
+		events = append(events, event)
+	}
+	file := writeBackupFile(t, t.TempDir(), FileCompressionGzip, events)
+
+	publisher := &recordingPublisher{}
+	replayer := NewReplayer(publisher, 0, 0)
+	replayer.server = "ca"
+	if err := replayer.ReplayFile(context.Background(), file); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+
+	if len(publisher.events) != 3 {
+		t.Fatalf("Expected 3 events from server ca, got %d", len(publisher.events))
+	}
+	for i, want := range []uint64{1, 2, 4} {
+		if publisher.events[i].Sequence != want {
+			t.Errorf("Expected event %d to have sequence %d, got %d", i, want, publisher.events[i].Sequence)
+		}
+	}
+
+	// Published messages must look like the ones a Quake Live server sends
+	msg, err := json.Marshal(publisher.events[0])
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+	if want := `{"TYPE":"PLAYER_KILL","DATA":{"TIME":1}}`; string(msg) != want {
+		t.Errorf("Unexpected wire format:\n got: %s\nwant: %s", msg, want)
+	}
+}
+
+func TestReplayPacing(t *testing.T) {
+	start := time.Date(2025, 4, 20, 20, 0, 0, 0, time.UTC)
+	offsets := []time.Duration{0, 10 * time.Second, 70 * time.Second, 72 * time.Second}
+	var events []Event
+	for i, offset := range offsets {
+		event := sequencedEvent(i + 1)
+		event.ReceivedAt = start.Add(offset)
+		events = append(events, event)
+	}
+	file := writeBackupFile(t, t.TempDir(), FileCompressionNone, events)
+
+	// Replay at 2x with pauses capped at 20s on a fake clock
+	clock := time.Unix(0, 0)
+	var waits []time.Duration
+	replayer := NewReplayer(&recordingPublisher{}, 2, 20*time.Second)
+	replayer.now = func() time.Time { return clock }
+	replayer.wait = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		clock = clock.Add(d)
+		return nil
+	}
+
+	if err := replayer.ReplayFile(context.Background(), file); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+
+	want := []time.Duration{5 * time.Second, 10 * time.Second, time.Second}
+	if len(waits) != len(want) {
+		t.Fatalf("Expected waits %v, got %v", want, waits)
+	}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Errorf("Expected waits %v, got %v", want, waits)
+			break
+		}
+	}
+}
+
+func TestZmqPublisherToCollector(t *testing.T) {
+	endpoint := "tcp://127.0.0.1:27972"
+	publisher, err := NewZmqPublisher(endpoint, "", 0)
+	if err != nil {
+		t.Fatalf("Failed to create publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	processor := &chanEventProcessor{events: make(chan Event, 100)}
+	collector, err := NewZmqCollector(ServerConfig{Name: "replay", Endpoint: endpoint}, processor)
+	if err != nil {
+		t.Fatalf("Failed to create ZMQ collector: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go collector.Run(ctx)
+
+	// Keep publishing until the subscriber has joined
+	for {
+		if err := publisher.Publish(sequencedEvent(1)); err != nil {
+			t.Fatalf("Failed to publish event: %v", err)
+		}
+		select {
+		case e := <-processor.events:
+			if e.Type != "PLAYER_KILL" || e.Server != "replay" || string(e.Data) != `{"TIME":1}` {
+				t.Errorf("Unexpected event received: %+v", e)
+			}
+			return
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for replayed event")
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"    // Writes go through
+	CircuitOpen     = "open"      // Writes fail fast or are spooled
+	CircuitHalfOpen = "half-open" // A single trial write decides whether to close again
+)
+
+// Actions taken on writes while the circuit is open
+const (
+	BreakerOpenFail  = "fail"  // Return ErrCircuitOpen so the caller handles the batch
+	BreakerOpenSpool = "spool" // Spool the batch and replay it once the circuit closes
+)
+
+// ErrCircuitOpen is returned for writes rejected by an open circuit breaker
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// ResilienceConfig contains configuration for the retry and circuit breaker
+// decorator
+type ResilienceConfig struct {
+	Retries          int           // Retries after a failed write
+	InitialBackoff   time.Duration // Backoff before the first retry, doubled for each one
+	MaxBackoff       time.Duration
+	FailureThreshold int           // Consecutive failed writes that open the circuit (0 disables the breaker)
+	OpenTimeout      time.Duration // How long the circuit stays open before a trial write
+	OpenAction       string
+	SpoolPath        string // Spool directory for the spool open action
+}
+
+// ResilientClient is a DBClient decorator that retries failed writes with
+// exponential backoff and jitter, and stops calling a failing client for a
+// while once its circuit breaker opens
+type ResilientClient struct {
+	name   string
+	next   DBClient
+	config ResilienceConfig
+	spool  *Spool
+	sleep  func(time.Duration)
+	now    func() time.Time
+
+	mu                  sync.Mutex
+	state               string
+	consecutiveFailures int
+	openedAt            time.Time
+	trialInFlight       bool
+	// Metrics
+	retries       int64
+	fastFailures  int64
+	timesOpened   int64
+	eventsSpooled int64
+	lastError     string
+}
+
+// NewResilientClient wraps a client with retries and a circuit breaker
+func NewResilientClient(name string, next DBClient, config ResilienceConfig) *ResilientClient {
+	c := &ResilientClient{
+		name:   name,
+		next:   next,
+		config: config,
+		sleep:  time.Sleep,
+		now:    time.Now,
+		state:  CircuitClosed,
+	}
+
+	if config.OpenAction == BreakerOpenSpool && config.FailureThreshold > 0 {
+		spool, err := NewSpool(SpoolConfig{Path: config.SpoolPath})
+		if err != nil {
+			log.Printf("Warning: Failed to initialize breaker spool for %s, failing fast instead: %v", name, err)
+		} else {
+			c.spool = spool
+		}
+	}
+	return c
+}
+
+// StoreEvents implements the DBClient interface
+func (c *ResilientClient) StoreEvents(events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	trial, allowed := c.allow()
+	if !allowed {
+		return c.rejectOpen(events)
+	}
+
+	// Batches spooled while the circuit was open are written first so the
+	// sink receives batches in order. Until they are, new batches are
+	// spooled behind them.
+	if err := c.replaySpool(); err != nil {
+		c.record(trial, err)
+		return c.spoolBehind(events, err)
+	}
+
+	// A trial write gets a single attempt
+	attempts := c.config.Retries + 1
+	if trial {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			c.mu.Lock()
+			c.retries++
+			c.mu.Unlock()
+			c.sleep(c.backoff(attempt))
+		}
+		if err = c.next.StoreEvents(events); err == nil {
+			break
+		}
+		log.Printf("Error storing events in %s (attempt %d/%d): %v", c.name, attempt+1, attempts, err)
+	}
+
+	c.record(trial, err)
+	return err
+}
+
+// allow reports whether a write may go through and whether it is the trial
+// write of a half-open circuit
+func (c *ResilientClient) allow() (trial bool, allowed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitOpen:
+		if c.now().Sub(c.openedAt) < c.config.OpenTimeout {
+			return false, false
+		}
+		c.setState(CircuitHalfOpen)
+		c.trialInFlight = true
+		return true, true
+	case CircuitHalfOpen:
+		if c.trialInFlight {
+			return false, false
+		}
+		c.trialInFlight = true
+		return true, true
+	}
+	return false, true
+}
+
+// record updates the breaker with the result of a write
+func (c *ResilientClient) record(trial bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if trial {
+		c.trialInFlight = false
+	}
+
+	if err == nil {
+		c.consecutiveFailures = 0
+		if c.state != CircuitClosed {
+			c.setState(CircuitClosed)
+		}
+		return
+	}
+
+	c.lastError = err.Error()
+	c.consecutiveFailures++
+	if c.config.FailureThreshold <= 0 {
+		return
+	}
+	if trial || c.consecutiveFailures >= c.config.FailureThreshold {
+		c.openedAt = c.now()
+		if c.state != CircuitOpen {
+			c.timesOpened++
+			c.setState(CircuitOpen)
+		}
+	}
+}
+
+// setState changes the breaker state. Must be called with the lock held.
+func (c *ResilientClient) setState(state string) {
+	log.Printf("Circuit breaker for %s is %s", c.name, state)
+	c.state = state
+}
+
+// rejectOpen handles a write while the circuit is open
+func (c *ResilientClient) rejectOpen(events []Event) error {
+	if c.spool != nil {
+		err := c.spool.Append(events)
+		if err == nil {
+			c.mu.Lock()
+			c.eventsSpooled += int64(len(events))
+			c.mu.Unlock()
+			return nil
+		}
+		log.Printf("Error spooling batch for %s while its circuit is open: %v", c.name, err)
+	}
+
+	c.mu.Lock()
+	c.fastFailures++
+	c.mu.Unlock()
+	return fmt.Errorf("%s: %w", c.name, ErrCircuitOpen)
+}
+
+// replaySpool writes batches spooled while the circuit was open, oldest
+// first, stopping at the first failure
+func (c *ResilientClient) replaySpool() error {
+	if c.spool == nil || c.spool.Pending() == 0 {
+		return nil
+	}
+	if _, err := c.spool.Replay(c.next); err != nil {
+		log.Printf("Error replaying spooled batches into %s: %v", c.name, err)
+		return err
+	}
+	return nil
+}
+
+// spoolBehind spools a batch behind the batches that could not be replayed
+func (c *ResilientClient) spoolBehind(events []Event, replayErr error) error {
+	if err := c.spool.Append(events); err != nil {
+		return fmt.Errorf("%s: failed to spool batch behind %d spooled batches (%v): %w", c.name, c.spool.Pending(), replayErr, err)
+	}
+	c.mu.Lock()
+	c.eventsSpooled += int64(len(events))
+	c.mu.Unlock()
+	return nil
+}
+
+// backoff returns the delay before the given retry: a random duration up to
+// the exponential backoff ("full jitter"), capped at the maximum backoff
+func (c *ResilientClient) backoff(retry int) time.Duration {
+	ceiling := c.config.InitialBackoff << (retry - 1)
+	if ceiling <= 0 || (c.config.MaxBackoff > 0 && ceiling > c.config.MaxBackoff) {
+		ceiling = c.config.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling + 1)
+}
+
+// State returns the current circuit breaker state
+func (c *ResilientClient) State() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// SinkName returns the name of the wrapped sink
+func (c *ResilientClient) SinkName() string {
+	return c.name
+}
+
+// Close implements the DBClient interface
+func (c *ResilientClient) Close() error {
+	return c.next.Close()
+}
+
+// GetMetrics implements the DBClient interface, adding the breaker state to
+// the metrics of the wrapped client
+func (c *ResilientClient) GetMetrics() map[string]interface{} {
+	metrics := make(map[string]interface{})
+	for k, v := range c.next.GetMetrics() {
+		metrics[k] = v
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	metrics["circuit_state"] = c.state
+	metrics["circuit_consecutive_failures"] = c.consecutiveFailures
+	metrics["circuit_times_opened"] = c.timesOpened
+	metrics["circuit_fast_failures"] = c.fastFailures
+	metrics["retries"] = c.retries
+	if c.lastError != "" {
+		metrics["last_error"] = c.lastError
+	}
+	if c.spool != nil {
+		metrics["circuit_events_spooled"] = c.eventsSpooled
+		metrics["circuit_spool_pending_batches"] = c.spool.Pending()
+	}
+	return metrics
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestResilientClient creates a resilient client with a fake clock that
+// records backoff sleeps instead of sleeping
+func newTestResilientClient(next DBClient, config ResilienceConfig) (*ResilientClient, *time.Time, *[]time.Duration) {
+	client := NewResilientClient("postgres", next, config)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	var sleeps []time.Duration
+	client.now = func() time.Time { return now }
+	client.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	return client, &now, &sleeps
+}
+
+func TestResilientClientRetriesWithBackoff(t *testing.T) {
+	calls := 0
+	next := &mockDBClient{storeEventsFunc: func(events []Event) error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection reset")
+		}
+		return nil
+	}}
+	client, _, sleeps := newTestResilientClient(next, ResilienceConfig{
+		Retries:        3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     150 * time.Millisecond,
+	})
+
+	if err := client.StoreEvents([]Event{sequencedEvent(1)}); err != nil {
+		t.Fatalf("Expected the write to succeed after retries, got %v", err)
+	}
+	if calls != 3 || len(*sleeps) != 2 {
+		t.Fatalf("Expected 3 attempts and 2 backoffs, got %d and %d", calls, len(*sleeps))
+	}
+	// Full jitter stays below the exponential backoff and the cap
+	if (*sleeps)[0] > 100*time.Millisecond || (*sleeps)[1] > 150*time.Millisecond {
+		t.Errorf("Unexpected backoffs %v", *sleeps)
+	}
+	if retries := client.GetMetrics()["retries"]; retries != int64(2) {
+		t.Errorf("Expected 2 retries in metrics, got %v", retries)
+	}
+}
+
+func TestResilientClientCircuitBreaker(t *testing.T) {
+	var storeErr error = errors.New("connection refused")
+	calls := 0
+	next := &mockDBClient{storeEventsFunc: func(events []Event) error {
+		calls++
+		return storeErr
+	}}
+	client, now, _ := newTestResilientClient(next, ResilienceConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      30 * time.Second,
+	})
+	events := []Event{sequencedEvent(1)}
+
+	client.StoreEvents(events)
+	if client.State() != CircuitClosed {
+		t.Fatalf("Expected the circuit to stay closed below the threshold, got %s", client.State())
+	}
+	client.StoreEvents(events)
+	if client.State() != CircuitOpen {
+		t.Fatalf("Expected the circuit to open at the threshold, got %s", client.State())
+	}
+
+	// Writes fail fast without calling the client while open
+	if err := client.StoreEvents(events); !errors.Is(err, ErrCircuitOpen) || calls != 2 {
+		t.Fatalf("Expected a fast failure, got %v after %d calls", err, calls)
+	}
+
+	// A failed trial write opens the circuit again
+	*now = now.Add(31 * time.Second)
+	if err := client.StoreEvents(events); err == nil || errors.Is(err, ErrCircuitOpen) || calls != 3 {
+		t.Fatalf("Expected a trial write, got %v after %d calls", err, calls)
+	}
+	if client.State() != CircuitOpen {
+		t.Fatalf("Expected the circuit to reopen after a failed trial, got %s", client.State())
+	}
+
+	// A successful trial write closes it
+	*now = now.Add(31 * time.Second)
+	storeErr = nil
+	if err := client.StoreEvents(events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	metrics := client.GetMetrics()
+	if metrics["circuit_state"] != CircuitClosed || metrics["circuit_times_opened"] != int64(2) ||
+		metrics["circuit_fast_failures"] != int64(1) || metrics["mock_client"] != true {
+		t.Errorf("Unexpected metrics: %v", metrics)
+	}
+}
+
+func TestResilientClientSpoolsWhileOpen(t *testing.T) {
+	var storeErr error = errors.New("connection refused")
+	next := &recordingClient{}
+	failing := &mockDBClient{storeEventsFunc: func(events []Event) error {
+		if storeErr != nil {
+			return storeErr
+		}
+		return next.StoreEvents(events)
+	}}
+	client, now, _ := newTestResilientClient(failing, ResilienceConfig{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Second,
+		OpenAction:       BreakerOpenSpool,
+		SpoolPath:        t.TempDir(),
+	})
+
+	client.StoreEvents([]Event{sequencedEvent(1)})
+	for i := 2; i <= 3; i++ {
+		if err := client.StoreEvents([]Event{sequencedEvent(i)}); err != nil {
+			t.Fatalf("Expected the batch to be spooled, got %v", err)
+		}
+	}
+
+	// A failed replay spools the trial batch behind the others
+	*now = now.Add(2 * time.Second)
+	if err := client.StoreEvents([]Event{sequencedEvent(4)}); err != nil {
+		t.Fatalf("Expected the trial batch to be spooled, got %v", err)
+	}
+	if state := client.State(); state != CircuitOpen {
+		t.Fatalf("Expected the failed replay to reopen the circuit, got %s", state)
+	}
+
+	// The spool is replayed before the trial batch once the sink recovers
+	*now = now.Add(2 * time.Second)
+	storeErr = nil
+	if err := client.StoreEvents([]Event{sequencedEvent(5)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if next.stored() != 4 {
+		t.Fatalf("Expected 3 spooled batches and the trial batch, got %d events", next.stored())
+	}
+	for i, e := range next.events {
+		if e.Sequence != uint64(i+2) {
+			t.Errorf("Expected batches in order, got %d at position %d", e.Sequence, i)
+		}
+	}
+	if pending := client.GetMetrics()["circuit_spool_pending_batches"]; pending != 0 {
+		t.Errorf("Expected an empty spool, got %v", pending)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"quake-stats/simulator"
+)
+
+// simulatedIntermission is the pause between two simulated matches
+const simulatedIntermission = 15 * time.Second
+
+// simulateMatches publishes matches from the simulators in turn, as if they
+// were played one after the other from start. The replayer paces them and
+// matches 0 plays until ctx is done. It returns the number of matches
+// published.
+func simulateMatches(ctx context.Context, replayer *Replayer, sims []*simulator.Simulator, matches int, start time.Time) (int, error) {
+	clock := start
+	for played := 0; matches == 0 || played < matches; played++ {
+		if ctx.Err() != nil {
+			return played, nil
+		}
+
+		events := sims[played%len(sims)].Match()
+		for _, generated := range events {
+			data, err := json.Marshal(generated.Data)
+			if err != nil {
+				return played, fmt.Errorf("failed to marshal %s: %w", generated.Type(), err)
+			}
+			event := Event{Type: generated.Type(), Data: data, ReceivedAt: clock.Add(generated.Time)}
+			if err := replayer.Publish(ctx, event); err != nil {
+				if ctx.Err() != nil {
+					return played, nil
+				}
+				return played, err
+			}
+		}
+		clock = clock.Add(events[len(events)-1].Time + simulatedIntermission)
+	}
+	return matches, nil
+}
+
+// SimulateTool is a command-line tool to publish simulated Quake Live matches
+// on a local ZMQ PUB socket, for end-to-end and load tests of the collector
+func SimulateTool() {
+	// Only run if we're specifically using the simulate command
+	if len(os.Args) < 2 || os.Args[1] != "simulate" {
+		return
+	}
+
+	// Configure simulate flags
+	simulateCmd := flag.NewFlagSet("simulate", flag.ExitOnError)
+	endpoint := simulateCmd.String("bind", "tcp://127.0.0.1:27960", "Endpoint to bind the PUB socket to")
+	password := simulateCmd.String("password", "", "Require subscribers to authenticate with this ZMQ PLAIN password")
+	gameTypes := simulateCmd.String("game-type", simulator.GameTypeCA,
+		"Comma-separated game types to play in turn ("+strings.Join(simulator.GameTypes(), ", ")+")")
+	players := simulateCmd.Int("players", 0, "Players per match (0 uses the game type default)")
+	killRate := simulateCmd.Float64("kill-rate", 2, "Frags per player per minute")
+	timeLimit := simulateCmd.Int("time-limit", 0, "Time limit in minutes (0 uses the game type default)")
+	fragLimit := simulateCmd.Int("frag-limit", 0, "Frag limit (0 uses the game type default)")
+	roundLimit := simulateCmd.Int("round-limit", 0, "Rounds to win in round-based game types (0 uses the game type default)")
+	matches := simulateCmd.Int("matches", 1, "Matches to play (0 plays until interrupted)")
+	speedFlag := simulateCmd.String("speed", "realtime", "Simulation speed: realtime, a multiplier such as 10x, or max")
+	seed := simulateCmd.Int64("seed", 0, "Seed for reproducible matches (0 picks a random one)")
+	startDelay := simulateCmd.Duration("start-delay", time.Second, "Wait this long after binding so subscribers can connect")
+	sendHWM := simulateCmd.Int("send-hwm", 0, "Messages queued per subscriber before ZMQ drops them (0 keeps the default of 1000)")
+
+	// Parse simulate flags (skip the "simulate" arg)
+	if err := simulateCmd.Parse(os.Args[2:]); err != nil {
+		log.Fatalf("Error parsing simulate flags: %v", err)
+	}
+
+	speed, err := ParseReplaySpeed(*speedFlag)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if *matches < 0 {
+		log.Fatalf("Error: -matches must not be negative")
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	var sims []*simulator.Simulator
+	for _, gameType := range strings.Split(*gameTypes, ",") {
+		if gameType = strings.TrimSpace(gameType); gameType == "" {
+			continue
+		}
+		sim, err := simulator.New(simulator.Config{
+			GameType:       gameType,
+			Players:        *players,
+			KillsPerMinute: *killRate,
+			TimeLimit:      *timeLimit,
+			FragLimit:      *fragLimit,
+			RoundLimit:     *roundLimit,
+			Seed:           *seed,
+		})
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		sims = append(sims, sim)
+	}
+	if len(sims) == 0 {
+		log.Fatalf("Error: -game-type must list at least one game type")
+	}
+
+	publisher, err := NewZmqPublisher(*endpoint, *password, *sendHWM)
+	if err != nil {
+		log.Fatalf("Error creating publisher: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	setupSignalHandling(cancel)
+
+	log.Printf("Publishing on %s at %s speed with seed %d, waiting %s for subscribers", *endpoint, *speedFlag, *seed, *startDelay)
+	if err := sleepContext(ctx, *startDelay); err != nil {
+		publisher.Close()
+		os.Exit(0)
+	}
+
+	start := time.Now()
+	replayer := NewReplayer(publisher, speed, 0)
+	played, err := simulateMatches(ctx, replayer, sims, *matches, start)
+	if err != nil {
+		publisher.Close()
+		log.Fatalf("Error simulating matches: %v", err)
+	}
+
+	fmt.Printf("Simulated %d matches, %d events in %s\n", played, replayer.published, time.Since(start).Round(time.Millisecond))
+	publisher.Close()
+	os.Exit(0)
+}
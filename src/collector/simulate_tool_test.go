@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"quake-stats/qlstats"
+	"quake-stats/simulator"
+)
+
+func TestSimulateMatchesThroughTracker(t *testing.T) {
+	var sims []*simulator.Simulator
+	for _, gameType := range []string{simulator.GameTypeDuel, simulator.GameTypeCA} {
+		sim, err := simulator.New(simulator.Config{GameType: gameType, Seed: 1})
+		if err != nil {
+			t.Fatalf("Failed to create simulator: %v", err)
+		}
+		sims = append(sims, sim)
+	}
+
+	publisher := &recordingPublisher{}
+	played, err := simulateMatches(context.Background(), NewReplayer(publisher, 0, 0), sims, 3, time.Now())
+	if err != nil || played != 3 {
+		t.Fatalf("Expected 3 matches, got %d: %v", played, err)
+	}
+
+	tracker := NewMatchTracker(time.Hour)
+	gameTypes := make(map[string]bool)
+	for i, e := range publisher.events {
+		if i > 0 && e.ReceivedAt.Before(publisher.events[i-1].ReceivedAt) {
+			t.Fatalf("Event %d was scheduled before the previous one", i)
+		}
+
+		// Before the report, the live scoreboard must agree with PLAYER_STATS
+		if e.Type == qlstats.TypePlayerStats {
+			decoded, err := e.Decode()
+			if err != nil {
+				t.Fatalf("Failed to decode PLAYER_STATS: %v", err)
+			}
+			stats := decoded.(*qlstats.PlayerStats)
+			match, ok := tracker.Match(stats.MatchGUID)
+			if !ok || !match.Started {
+				t.Fatalf("Expected match %s to be tracked", stats.MatchGUID)
+			}
+			gameTypes[match.GameType] = true
+			if player := match.Players[stats.SteamID]; player == nil || player.Deaths != stats.Deaths {
+				t.Errorf("Expected %s to have %d deaths, tracked %+v", stats.Name, stats.Deaths, player)
+			}
+		}
+		tracker.ConsumeEvent(e)
+	}
+
+	metrics := tracker.GetMetrics()
+	if metrics["matches_started"] != int64(3) || metrics["matches_finished"] != int64(3) {
+		t.Errorf("Expected 3 started and finished matches, got %v", metrics)
+	}
+	if !gameTypes["DUEL"] || !gameTypes["CA"] {
+		t.Errorf("Expected duel and CA matches, got %v", gameTypes)
+	}
+}
@@ -0,0 +1,870 @@
+// Package simulator generates believable Quake Live matches for load and
+// integration testing of the collector. Events use the qlstats models, so
+// they marshal to the payloads a server publishes on its stats socket.
+package simulator
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+
+	"quake-stats/qlstats"
+)
+
+// Game types the simulator can play
+const (
+	GameTypeFFA  = "ffa"
+	GameTypeDuel = "duel"
+	GameTypeTDM  = "tdm"
+	GameTypeCA   = "ca"
+)
+
+// gameType holds the settings Quake Live uses for a factory
+type gameType struct {
+	title      string
+	gameType   string
+	teams      bool
+	rounds     bool
+	players    int // Default player count
+	minPlayers int
+	maxPlayers int
+	fragLimit  int
+	timeLimit  int // Minutes
+	roundLimit int
+	maps       []string
+}
+
+var gameTypes = map[string]gameType{
+	GameTypeFFA: {title: "Free For All", gameType: "FFA", players: 8, minPlayers: 2, maxPlayers: 16,
+		fragLimit: 50, timeLimit: 15, maps: []string{"aerowalk", "almostlost", "bloodrun", "campgrounds", "toxicity"}},
+	GameTypeDuel: {title: "Duel", gameType: "DUEL", players: 2, minPlayers: 2, maxPlayers: 2,
+		timeLimit: 10, maps: []string{"aerowalk", "bloodrun", "cure", "furiousheights", "sinister", "toxicity"}},
+	GameTypeTDM: {title: "Team Deathmatch", gameType: "TDM", teams: true, players: 8, minPlayers: 2, maxPlayers: 16,
+		fragLimit: 150, timeLimit: 15, maps: []string{"dreadfulplace", "hiddenfortress", "purgatory", "verticalvengeance"}},
+	GameTypeCA: {title: "Clan Arena", gameType: "CA", teams: true, rounds: true, players: 8, minPlayers: 2, maxPlayers: 16,
+		fragLimit: 50, roundLimit: 10, maps: []string{"campgrounds", "overkill", "quarantine", "theatreofpain", "trinity"}},
+}
+
+// GameTypes returns the game types the simulator can play
+func GameTypes() []string {
+	types := make([]string, 0, len(gameTypes))
+	for t := range gameTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Config configures the simulated matches. Zero values use the defaults of
+// the game type.
+type Config struct {
+	GameType       string
+	Players        int
+	KillsPerMinute float64 // Frags per player per minute
+	TimeLimit      int     // Minutes (0 uses the game type default)
+	FragLimit      int
+	RoundLimit     int // Rounds a team must win in round-based game types
+	ServerTitle    string
+	Seed           int64 // Matches generated with the same seed are identical
+}
+
+// Event is a generated event and when it happens, relative to the first
+// event of its match
+type Event struct {
+	Time time.Duration
+	Data qlstats.Event
+}
+
+// Type returns the TYPE the event is published with
+func (e Event) Type() string {
+	return e.Data.EventType()
+}
+
+// Simulator generates matches. It is not safe for concurrent use.
+type Simulator struct {
+	cfg     Config
+	game    gameType
+	rng     *rand.Rand
+	players []*player
+}
+
+// New creates a simulator for the configured game type
+func New(cfg Config) (*Simulator, error) {
+	cfg.GameType = strings.ToLower(cfg.GameType)
+	game, ok := gameTypes[cfg.GameType]
+	if !ok {
+		return nil, fmt.Errorf("unknown game type %q (available: %s)", cfg.GameType, strings.Join(GameTypes(), ", "))
+	}
+	if cfg.Players == 0 {
+		cfg.Players = game.players
+	}
+	if cfg.Players < game.minPlayers || cfg.Players > game.maxPlayers {
+		return nil, fmt.Errorf("%s needs %d to %d players, got %d", cfg.GameType, game.minPlayers, game.maxPlayers, cfg.Players)
+	}
+	if cfg.KillsPerMinute < 0 || cfg.TimeLimit < 0 || cfg.FragLimit < 0 || cfg.RoundLimit < 0 {
+		return nil, fmt.Errorf("kill rate and limits must not be negative")
+	}
+	if cfg.KillsPerMinute == 0 {
+		cfg.KillsPerMinute = 2
+	}
+	if cfg.TimeLimit == 0 {
+		cfg.TimeLimit = game.timeLimit
+	}
+	if cfg.FragLimit == 0 {
+		cfg.FragLimit = game.fragLimit
+	}
+	if cfg.RoundLimit == 0 {
+		cfg.RoundLimit = game.roundLimit
+	}
+	if cfg.ServerTitle == "" {
+		cfg.ServerTitle = "quake-stats simulator"
+	}
+
+	// Simulators of different game types sharing a seed must not generate
+	// the same match GUIDs
+	stream := fnv.New64a()
+	stream.Write([]byte(cfg.GameType))
+	s := &Simulator{
+		cfg:  cfg,
+		game: game,
+		rng:  rand.New(rand.NewPCG(uint64(cfg.Seed), stream.Sum64())),
+	}
+
+	// The same players come back for every match, like the regulars of a
+	// server
+	for i := 0; i < cfg.Players; i++ {
+		name := playerNames[i%len(playerNames)]
+		if i >= len(playerNames) {
+			name = fmt.Sprintf("%s%d", name, i/len(playerNames)+1)
+		}
+		s.players = append(s.players, &player{
+			name:    name,
+			steamID: fmt.Sprintf("7656119%010d", s.rng.Int64N(10_000_000_000)),
+			model:   playerModels[s.rng.IntN(len(playerModels))],
+			skill:   0.5 + s.rng.Float64(),
+		})
+	}
+	return s, nil
+}
+
+// Config returns the configuration with the game type defaults applied
+func (s *Simulator) Config() Config {
+	return s.cfg
+}
+
+// Match generates a complete match: players connecting in warmup,
+// MATCH_STARTED, frags with their medals, ROUND_OVER in round-based game
+// types, PLAYER_STATS for every player and MATCH_REPORT
+func (s *Simulator) Match() []Event {
+	m := &match{
+		sim:        s,
+		guid:       s.newGUID(),
+		mapName:    s.game.maps[s.rng.IntN(len(s.game.maps))],
+		teamScores: make(map[qlstats.Team]int),
+	}
+	m.start(s.players)
+	if s.game.rounds {
+		m.playRounds()
+	} else {
+		m.play()
+	}
+	m.finish()
+	return m.events
+}
+
+// newGUID returns a random version 4 UUID
+func (s *Simulator) newGUID() string {
+	var b [16]byte
+	for i := 0; i < len(b); i += 8 {
+		v := s.rng.Uint64()
+		for j := 0; j < 8; j++ {
+			b[i+j] = byte(v >> (8 * j))
+		}
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+var playerNames = []string{
+	"rapha", "cypher", "toxjq", "evil", "clawz", "k1llsen", "strenx", "vo0",
+	"agent", "spart1e", "latrommi", "zlatan", "dahang", "krysa", "garz", "drwlf",
+}
+
+var playerModels = []string{"sarge", "visor", "ranger", "keel", "anarki", "doom", "slash", "xaero"}
+
+// Medals and pickups listed in PLAYER_STATS
+var (
+	medalNames = []string{
+		"ACCURACY", "ASSISTS", "CAPTURES", "COMBOKILL", "DEFENDS", "EXCELLENT", "FIRSTFRAG", "HEADSHOT",
+		"HUMILIATION", "IMPRESSIVE", "MIDAIR", "PERFECT", "PERFORATED", "QUADGOD", "RAMPAGE", "REVENGE",
+	}
+	pickupNames = []string{
+		"AMMO", "ARMOR", "ARMOR_REGEN", "BATTLESUIT", "DOUBLER", "FLIGHT", "GREEN_ARMOR", "GUARD",
+		"HASTE", "HEALTH", "INVIS", "INVULNERABILITY", "KAMIKAZE", "MEDKIT", "MEGA_HEALTH", "OTHER_HOLDABLE",
+		"OTHER_POWERUP", "PORTAL", "QUAD", "RED_ARMOR", "REGEN", "SCOUT", "TELEPORTER", "YELLOW_ARMOR",
+	}
+	weaponNames = []string{
+		"BFG", "CHAINGUN", "GAUNTLET", "GRENADE", "HMG", "LIGHTNING", "MACHINEGUN", "NAILGUN",
+		"OTHER_WEAPON", "PLASMA", "PROXMINE", "RAILGUN", "ROCKET", "SHOTGUN",
+	}
+)
+
+// weapon is a weapon frags are made with
+type weapon struct {
+	name     string
+	weight   int      // Share of frags
+	mods     []string // Means of death
+	accuracy float64
+	damage   int // Damage of a hit
+}
+
+var weapons = []weapon{
+	{name: "ROCKET", weight: 30, mods: []string{"ROCKET", "ROCKET_SPLASH"}, accuracy: 0.4, damage: 100},
+	{name: "LIGHTNING", weight: 20, mods: []string{"LIGHTNING"}, accuracy: 0.33, damage: 6},
+	{name: "RAILGUN", weight: 20, mods: []string{"RAILGUN"}, accuracy: 0.45, damage: 100},
+	{name: "SHOTGUN", weight: 10, mods: []string{"SHOTGUN"}, accuracy: 0.3, damage: 5},
+	{name: "PLASMA", weight: 8, mods: []string{"PLASMA", "PLASMA_SPLASH"}, accuracy: 0.25, damage: 20},
+	{name: "GRENADE", weight: 5, mods: []string{"GRENADE", "GRENADE_SPLASH"}, accuracy: 0.15, damage: 100},
+	{name: "MACHINEGUN", weight: 5, mods: []string{"MACHINEGUN"}, accuracy: 0.35, damage: 5},
+	{name: "GAUNTLET", weight: 2, mods: []string{"GAUNTLET"}, accuracy: 0.9, damage: 50},
+}
+
+// worldDeaths are means of death without a killer
+var worldDeaths = []string{"FALLING", "LAVA", "TRIGGER_HURT", "CRUSH"}
+
+// player is a simulated player and their statistics in the current match
+type player struct {
+	name    string
+	steamID string
+	model   string
+	skill   float64 // Relative chance to frag and survive
+
+	team       qlstats.Team
+	alive      bool
+	score      int
+	kills      int
+	deaths     int
+	streak     int
+	maxStreak  int
+	lastFrag   time.Duration
+	lastWeapon string
+	lastKiller *player
+	damage     qlstats.Damage
+	medals     map[string]int
+	weapons    map[string]qlstats.WeaponStats
+}
+
+// reset clears the statistics of the previous match
+func (p *player) reset(team qlstats.Team) {
+	p.team = team
+	p.alive = true
+	p.score, p.kills, p.deaths, p.streak, p.maxStreak = 0, 0, 0, 0, 0
+	p.lastFrag = -1
+	p.lastWeapon = ""
+	p.lastKiller = nil
+	p.damage = qlstats.Damage{}
+	p.medals = make(map[string]int, len(medalNames))
+	for _, medal := range medalNames {
+		p.medals[medal] = 0
+	}
+	p.weapons = make(map[string]qlstats.WeaponStats, len(weaponNames))
+	for _, name := range weaponNames {
+		p.weapons[name] = qlstats.WeaponStats{}
+	}
+}
+
+// match is the state of the match being generated
+type match struct {
+	sim        *Simulator
+	guid       string
+	mapName    string
+	players    []*player
+	events     []Event
+	now        time.Duration // Since the first event
+	startedAt  time.Duration // When MATCH_STARTED was published
+	round      int
+	teamScores map[qlstats.Team]int
+	exitMsg    string
+
+	firstScorer    string
+	lastScorer     string
+	lastTeamScorer string
+	leader         string
+	lastLeadChange time.Duration
+}
+
+// emit appends an event at the current time
+func (m *match) emit(event qlstats.Event) {
+	m.events = append(m.events, Event{Time: m.now, Data: event})
+}
+
+// elapsed returns the TIME of events: seconds since the match started
+func (m *match) elapsed() int {
+	if m.now < m.startedAt {
+		return 0
+	}
+	return int((m.now - m.startedAt) / time.Second)
+}
+
+// start connects the players in warmup and starts the match
+func (m *match) start(roster []*player) {
+	rng := m.sim.rng
+	m.players = append([]*player(nil), roster...)
+	rng.Shuffle(len(m.players), func(i, j int) { m.players[i], m.players[j] = m.players[j], m.players[i] })
+	for i, p := range m.players {
+		team := qlstats.TeamFree
+		if m.sim.game.teams {
+			team = qlstats.TeamRed + qlstats.Team(i%2)
+		}
+		p.reset(team)
+	}
+
+	for _, p := range m.players {
+		m.emit(&qlstats.PlayerConnect{MatchGUID: m.guid, Name: p.name, SteamID: p.steamID, Warmup: true})
+		m.now += time.Duration(500+rng.IntN(2500)) * time.Millisecond
+	}
+
+	// Warmup until everyone readied up
+	m.now += time.Duration(10+rng.IntN(20)) * time.Second
+	m.startedAt = m.now
+
+	cfg := m.sim.cfg
+	started := &qlstats.MatchStarted{
+		CaptureLimit: 8,
+		Factory:      cfg.GameType,
+		FactoryTitle: m.sim.game.title,
+		FragLimit:    cfg.FragLimit,
+		GameType:     m.sim.game.gameType,
+		Map:          m.mapName,
+		MatchGUID:    m.guid,
+		RoundLimit:   cfg.RoundLimit,
+		ScoreLimit:   150,
+		ServerTitle:  cfg.ServerTitle,
+		TimeLimit:    cfg.TimeLimit,
+	}
+	for _, p := range m.players {
+		started.Players = append(started.Players, qlstats.MatchPlayer{Name: p.name, SteamID: p.steamID, Team: p.team})
+	}
+	m.emit(started)
+}
+
+// nextFrag advances the clock to the next frag
+func (m *match) nextFrag(alive int) {
+	rate := m.sim.cfg.KillsPerMinute * float64(alive) / 60
+	m.now += time.Duration(m.sim.rng.ExpFloat64() / rate * float64(time.Second))
+}
+
+// timeUp reports whether the time limit was hit
+func (m *match) timeUp() bool {
+	limit := m.sim.cfg.TimeLimit
+	return limit > 0 && m.now-m.startedAt >= time.Duration(limit)*time.Minute
+}
+
+// play generates frags until the time or frag limit is hit
+func (m *match) play() {
+	for {
+		m.nextFrag(len(m.players))
+		if m.timeUp() {
+			m.now = m.startedAt + time.Duration(m.sim.cfg.TimeLimit)*time.Minute
+			m.exitMsg = "Timelimit hit."
+			return
+		}
+		m.frag()
+		if m.fragLimitHit() {
+			m.exitMsg = "Fraglimit hit."
+			return
+		}
+	}
+}
+
+// fragLimitHit reports whether a player, or a team in team game types,
+// reached the frag limit
+func (m *match) fragLimitHit() bool {
+	limit := m.sim.cfg.FragLimit
+	if limit <= 0 {
+		return false
+	}
+	if m.sim.game.teams {
+		return m.teamScores[qlstats.TeamRed] >= limit || m.teamScores[qlstats.TeamBlue] >= limit
+	}
+	for _, p := range m.players {
+		if p.score >= limit {
+			return true
+		}
+	}
+	return false
+}
+
+// playRounds generates rounds until a team won the round limit
+func (m *match) playRounds() {
+	limit := m.sim.cfg.RoundLimit
+	for m.teamScores[qlstats.TeamRed] < limit && m.teamScores[qlstats.TeamBlue] < limit {
+		if m.timeUp() {
+			m.exitMsg = "Timelimit hit."
+			return
+		}
+
+		// Round countdown
+		m.round++
+		m.now += 10 * time.Second
+		for _, p := range m.players {
+			p.alive = true
+		}
+
+		for m.aliveCount(qlstats.TeamRed) > 0 && m.aliveCount(qlstats.TeamBlue) > 0 {
+			m.nextFrag(m.aliveCount(qlstats.TeamRed) + m.aliveCount(qlstats.TeamBlue))
+			m.frag()
+		}
+
+		winner := qlstats.TeamRed
+		if m.aliveCount(qlstats.TeamRed) == 0 {
+			winner = qlstats.TeamBlue
+		}
+		m.teamScores[winner]++
+		m.lastTeamScorer = winner.String()
+		m.updateLeader()
+		m.now += time.Second
+		m.emit(&qlstats.RoundOver{MatchGUID: m.guid, Round: m.round, TeamWon: winner, Time: m.elapsed()})
+		m.now += 2 * time.Second
+	}
+	m.exitMsg = "Roundlimit hit."
+}
+
+// aliveCount returns the number of players of a team that are alive
+func (m *match) aliveCount(team qlstats.Team) int {
+	n := 0
+	for _, p := range m.players {
+		if p.team == team && p.alive {
+			n++
+		}
+	}
+	return n
+}
+
+// pick returns a random alive player that passes filter, weighted by skill
+// (or its inverse)
+func (m *match) pick(filter func(*player) bool, inverse bool) *player {
+	var candidates []*player
+	var total float64
+	for _, p := range m.players {
+		if p.alive && filter(p) {
+			candidates = append(candidates, p)
+			total += weight(p, inverse)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	r := m.sim.rng.Float64() * total
+	for _, p := range candidates {
+		r -= weight(p, inverse)
+		if r < 0 {
+			return p
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+func weight(p *player, inverse bool) float64 {
+	if inverse {
+		return 1 / p.skill
+	}
+	return p.skill
+}
+
+// frag generates a frag: a kill, a suicide or a death to the world
+func (m *match) frag() {
+	rng := m.sim.rng
+	killer := m.pick(func(*player) bool { return true }, false)
+	switch r := rng.Float64(); {
+	case r < 0.03:
+		m.worldDeath(m.pick(func(*player) bool { return true }, true))
+	case r < 0.05:
+		m.kill(killer, killer, weapons[0])
+	default:
+		victim := m.pick(func(p *player) bool {
+			if m.sim.game.teams {
+				return p.team != killer.team
+			}
+			return p != killer
+		}, true)
+		m.kill(killer, victim, m.pickWeapon())
+	}
+}
+
+// pickWeapon returns a random weapon, weighted by its share of frags
+func (m *match) pickWeapon() weapon {
+	total := 0
+	for _, w := range weapons {
+		total += w.weight
+	}
+	r := m.sim.rng.IntN(total)
+	for _, w := range weapons {
+		r -= w.weight
+		if r < 0 {
+			return w
+		}
+	}
+	return weapons[0]
+}
+
+// kill records a frag of victim by killer, which is a suicide when they are
+// the same player
+func (m *match) kill(killer, victim *player, w weapon) {
+	rng := m.sim.rng
+	suicide := killer == victim
+	mod := w.mods[rng.IntN(len(w.mods))]
+
+	// Damage to take the victim from full stack to dead
+	damage := 100 + rng.IntN(150)
+	hits := int(math.Max(1, math.Round(float64(damage)/float64(w.damage))))
+	shots := int(math.Ceil(float64(hits) / w.accuracy))
+
+	victimWeapon := weapons[rng.IntN(len(weapons))].name
+	victimState := m.participant(victim, victimWeapon)
+	victimState.Health = -rng.IntN(60)
+	victimState.Streak = victim.streak
+	var killerState qlstats.KillParticipant
+	if suicide {
+		killerState = victimState
+		mod = w.mods[len(w.mods)-1]
+	} else {
+		killerState = m.participant(killer, w.name)
+		killerState.Health = 1 + rng.IntN(200)
+	}
+
+	if !suicide {
+		stats := killer.weapons[w.name]
+		stats.Shots += shots
+		stats.Hits += hits
+		stats.DamageGiven += damage
+		stats.Kills++
+		killer.weapons[w.name] = stats
+		killer.damage.Dealt += damage
+	}
+	stats := victim.weapons[w.name]
+	stats.Deaths++
+	stats.DamageReceived += damage
+	victim.weapons[w.name] = stats
+	victim.damage.Taken += damage
+
+	event := qlstats.Kill{
+		Killer:    &killerState,
+		MatchGUID: m.guid,
+		MOD:       mod,
+		Suicide:   qlstats.Bool(suicide),
+		Time:      m.elapsed(),
+		Victim:    victimState,
+	}
+	m.die(victim, &event)
+	m.emit(&qlstats.PlayerKill{Kill: event})
+	m.emit(&qlstats.PlayerDeath{Kill: event})
+
+	if suicide {
+		m.score(killer, -1)
+		return
+	}
+
+	killer.kills++
+	killer.streak++
+	if killer.streak > killer.maxStreak {
+		killer.maxStreak = killer.streak
+	}
+	m.score(killer, 1)
+	m.medals(killer, victim, w, mod, victimState.Airborne)
+	killer.lastFrag = m.now
+	killer.lastWeapon = w.name
+	victim.lastKiller = killer
+}
+
+// worldDeath records a death without a killer, e.g. falling into the void
+func (m *match) worldDeath(victim *player) {
+	victimState := m.participant(victim, weapons[m.sim.rng.IntN(len(weapons))].name)
+	victimState.Health = 0
+	victimState.Streak = victim.streak
+	event := qlstats.Kill{
+		MatchGUID: m.guid,
+		MOD:       worldDeaths[m.sim.rng.IntN(len(worldDeaths))],
+		Time:      m.elapsed(),
+		Victim:    victimState,
+	}
+	m.die(victim, &event)
+	m.emit(&qlstats.PlayerDeath{Kill: event})
+	m.score(victim, -1)
+	victim.lastKiller = nil
+}
+
+// die records the death of the victim. In round-based game types the victim
+// stays dead until the next round and the kill carries the team counters
+// from the killer's point of view.
+func (m *match) die(victim *player, event *qlstats.Kill) {
+	victim.deaths++
+	victim.streak = 0
+	if !m.sim.game.rounds {
+		return
+	}
+
+	victim.alive = false
+	team := victim.team
+	if event.Killer != nil && event.Killer.Team != victim.team {
+		team = event.Killer.Team
+	}
+	other := qlstats.TeamBlue
+	if team == qlstats.TeamBlue {
+		other = qlstats.TeamRed
+	}
+	teamAlive, otherAlive := m.aliveCount(team), m.aliveCount(other)
+	teamDead, otherDead := m.teamSize(team)-teamAlive, m.teamSize(other)-otherAlive
+	round := m.round
+	event.Round = &round
+	event.TeamAlive, event.TeamDead = &teamAlive, &teamDead
+	event.OtherTeamAlive, event.OtherTeamDead = &otherAlive, &otherDead
+}
+
+// teamSize returns the number of players in a team
+func (m *match) teamSize(team qlstats.Team) int {
+	n := 0
+	for _, p := range m.players {
+		if p.team == team {
+			n++
+		}
+	}
+	return n
+}
+
+// participant returns the state of a player taking part in a frag
+func (m *match) participant(p *player, weapon string) qlstats.KillParticipant {
+	rng := m.sim.rng
+	state := qlstats.KillParticipant{
+		Airborne: qlstats.Bool(rng.Float64() < 0.25),
+		Ammo:     rng.IntN(100),
+		Armor:    rng.IntN(150),
+		Health:   rng.IntN(200),
+		Name:     p.name,
+		Position: qlstats.Vector{
+			X: rng.Float64()*4000 - 2000,
+			Y: rng.Float64()*4000 - 2000,
+			Z: rng.Float64() * 800,
+		},
+		Speed:   rng.Float64() * 900,
+		SteamID: p.steamID,
+		Team:    p.team,
+		View: qlstats.Vector{
+			X: rng.Float64()*60 - 30,
+			Y: rng.Float64()*360 - 180,
+		},
+		Weapon: weapon,
+	}
+	if rng.Float64() < 0.05 {
+		state.Powerups = []string{"QUAD"}
+	}
+	return state
+}
+
+// score adds to the score of a player and their team. In round-based game
+// types teams score by winning rounds.
+func (m *match) score(p *player, points int) {
+	p.score += points
+	if points > 0 {
+		if m.firstScorer == "" {
+			m.firstScorer = p.name
+		}
+		m.lastScorer = p.name
+	}
+	if m.sim.game.teams && !m.sim.game.rounds {
+		m.teamScores[p.team] += points
+		if points > 0 {
+			m.lastTeamScorer = p.team.String()
+		}
+	}
+	m.updateLeader()
+}
+
+// updateLeader records when the lead last changed hands
+func (m *match) updateLeader() {
+	leader := ""
+	if m.sim.game.teams {
+		red, blue := m.teamScores[qlstats.TeamRed], m.teamScores[qlstats.TeamBlue]
+		if red > blue {
+			leader = qlstats.TeamRed.String()
+		} else if blue > red {
+			leader = qlstats.TeamBlue.String()
+		}
+	} else {
+		best := math.MinInt
+		for _, p := range m.players {
+			if p.score > best {
+				best, leader = p.score, p.name
+			} else if p.score == best {
+				leader = ""
+			}
+		}
+	}
+	if leader != "" && leader != m.leader {
+		m.leader = leader
+		m.lastLeadChange = m.now - m.startedAt
+	}
+}
+
+// medals awards the medals earned by a frag
+func (m *match) medals(killer, victim *player, w weapon, mod string, airborne qlstats.Bool) {
+	var earned []string
+	if m.countMedal("FIRSTFRAG") == 0 {
+		earned = append(earned, "FIRSTFRAG")
+	}
+	if killer.lastFrag >= 0 && m.now-killer.lastFrag <= 2*time.Second {
+		earned = append(earned, "EXCELLENT")
+	}
+	if w.name == "RAILGUN" && killer.lastWeapon == "RAILGUN" {
+		earned = append(earned, "IMPRESSIVE")
+	}
+	if w.name == "GAUNTLET" {
+		earned = append(earned, "HUMILIATION")
+	}
+	if mod == "ROCKET" && airborne {
+		earned = append(earned, "MIDAIR")
+	}
+	if killer.lastKiller == victim {
+		earned = append(earned, "REVENGE")
+	}
+
+	for _, medal := range earned {
+		killer.medals[medal]++
+		m.emit(&qlstats.PlayerMedal{
+			MatchGUID: m.guid,
+			Medal:     medal,
+			Name:      killer.name,
+			SteamID:   killer.steamID,
+			Time:      m.elapsed(),
+			Total:     killer.medals[medal],
+		})
+	}
+}
+
+// countMedal returns how often a medal was awarded in the match
+func (m *match) countMedal(medal string) int {
+	n := 0
+	for _, p := range m.players {
+		n += p.medals[medal]
+	}
+	return n
+}
+
+// finish publishes the statistics of every player and the match report
+func (m *match) finish() {
+	rng := m.sim.rng
+	m.now += time.Second
+	gameLength := m.elapsed()
+	red, blue := m.teamScores[qlstats.TeamRed], m.teamScores[qlstats.TeamBlue]
+
+	for _, p := range m.players {
+		// Clan Arena scores damage as well as frags
+		score := p.score
+		if m.sim.game.rounds {
+			score = p.kills + p.damage.Dealt/100
+		}
+		p.score = score
+	}
+
+	for _, p := range m.players {
+		rank, tied := m.rank(p, func(*player) bool { return true })
+		teamRank, teamTied := m.rank(p, func(o *player) bool { return o.team == p.team })
+
+		win := rank == 1 && tied == 1
+		lose := !win
+		if m.sim.game.teams {
+			win = (p.team == qlstats.TeamRed && red > blue) || (p.team == qlstats.TeamBlue && blue > red)
+			lose = !win && red != blue
+		}
+
+		pickups := make(map[string]int, len(pickupNames))
+		for _, name := range pickupNames {
+			pickups[name] = 0
+		}
+		minutes := gameLength/60 + 1
+		pickups["HEALTH"] = rng.IntN(4 * minutes)
+		pickups["AMMO"] = rng.IntN(3 * minutes)
+		pickups["YELLOW_ARMOR"] = rng.IntN(2 * minutes)
+		pickups["RED_ARMOR"] = rng.IntN(minutes)
+		pickups["MEGA_HEALTH"] = rng.IntN(minutes)
+
+		held := make(map[string]qlstats.WeaponStats, len(p.weapons))
+		for _, name := range weaponNames {
+			stats := p.weapons[name]
+			if stats.Shots > 0 {
+				stats.Pickups = 1 + rng.IntN(minutes)
+				stats.TimeHeld = 1 + rng.IntN(gameLength/4+1)
+			}
+			held[name] = stats
+		}
+
+		m.emit(&qlstats.PlayerStats{
+			Damage:       p.damage,
+			Deaths:       p.deaths,
+			Kills:        p.kills,
+			Lose:         qlstats.Bool(lose),
+			MatchGUID:    m.guid,
+			MaxStreak:    p.maxStreak,
+			Medals:       p.medals,
+			Model:        p.model,
+			Name:         p.name,
+			Pickups:      pickups,
+			PlayTime:     gameLength,
+			Rank:         rank,
+			Score:        p.score,
+			SteamID:      p.steamID,
+			Team:         p.team,
+			TeamRank:     teamRank,
+			TiedRank:     tied,
+			TiedTeamRank: teamTied,
+			Weapons:      held,
+			Win:          qlstats.Bool(win),
+		})
+	}
+
+	report := &qlstats.MatchReport{
+		CaptureLimit:       8,
+		ExitMsg:            m.exitMsg,
+		Factory:            m.sim.cfg.GameType,
+		FactoryTitle:       m.sim.game.title,
+		FirstScorer:        qlstats.OptionalString(m.firstScorer),
+		FragLimit:          m.sim.cfg.FragLimit,
+		GameLength:         gameLength,
+		GameType:           m.sim.game.gameType,
+		LastLeadChangeTime: int(m.lastLeadChange / time.Millisecond),
+		LastScorer:         qlstats.OptionalString(m.lastScorer),
+		LastTeamScorer:     qlstats.OptionalString(m.lastTeamScorer),
+		Map:                m.mapName,
+		MatchGUID:          m.guid,
+		RoundLimit:         m.sim.cfg.RoundLimit,
+		ScoreLimit:         150,
+		ServerTitle:        m.sim.cfg.ServerTitle,
+		TimeLimit:          m.sim.cfg.TimeLimit,
+	}
+	if m.sim.game.teams {
+		report.TeamScoreRed, report.TeamScoreBlue = red, blue
+	}
+	m.emit(report)
+}
+
+// rank returns the rank of a player among the players that pass filter, and
+// the number of them sharing that score
+func (m *match) rank(p *player, filter func(*player) bool) (int, int) {
+	rank, tied := 1, 0
+	for _, o := range m.players {
+		if !filter(o) {
+			continue
+		}
+		if o.score > p.score {
+			rank++
+		} else if o.score == p.score {
+			tied++
+		}
+	}
+	return rank, tied
+}
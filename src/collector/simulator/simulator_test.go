@@ -0,0 +1,144 @@
+package simulator
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"quake-stats/qlstats"
+)
+
+func TestMatchIsDeterministic(t *testing.T) {
+	generate := func() []byte {
+		sim, err := New(Config{GameType: GameTypeCA, Seed: 42})
+		if err != nil {
+			t.Fatalf("Failed to create simulator: %v", err)
+		}
+		sim.Match()
+		data, err := json.Marshal(sim.Match())
+		if err != nil {
+			t.Fatalf("Failed to marshal match: %v", err)
+		}
+		return data
+	}
+	if first, second := generate(), generate(); !reflect.DeepEqual(first, second) {
+		t.Error("Expected matches generated with the same seed to be identical")
+	}
+}
+
+func TestMatchEvents(t *testing.T) {
+	for _, gameType := range GameTypes() {
+		t.Run(gameType, func(t *testing.T) {
+			sim, err := New(Config{GameType: gameType, Seed: 7})
+			if err != nil {
+				t.Fatalf("Failed to create simulator: %v", err)
+			}
+			cfg := sim.Config()
+			events := sim.Match()
+
+			counts := make(map[string]int)
+			kills, deaths, statKills, statDeaths := 0, 0, 0, 0
+			guid := ""
+			for i, event := range events {
+				// Events survive the trip through the wire format
+				data, err := json.Marshal(event.Data)
+				if err != nil {
+					t.Fatalf("Failed to marshal %s: %v", event.Type(), err)
+				}
+				decoded, err := qlstats.Decode(event.Type(), data)
+				if err != nil {
+					t.Fatalf("Failed to decode %s: %v", event.Type(), err)
+				}
+
+				if guid == "" {
+					guid = decoded.MatchID()
+				}
+				if decoded.MatchID() != guid {
+					t.Errorf("Event %d belongs to match %s, expected %s", i, decoded.MatchID(), guid)
+				}
+				if i > 0 && event.Time < events[i-1].Time {
+					t.Errorf("Event %d at %s precedes the previous one at %s", i, event.Time, events[i-1].Time)
+				}
+				counts[event.Type()]++
+
+				switch ev := decoded.(type) {
+				case *qlstats.PlayerKill:
+					if !ev.Suicide {
+						kills++
+					}
+					if (ev.Round != nil) != (gameType == GameTypeCA) {
+						t.Errorf("Expected ROUND only in round-based game types, got %v", ev.Round)
+					}
+				case *qlstats.PlayerDeath:
+					deaths++
+				case *qlstats.PlayerStats:
+					statKills += ev.Kills
+					statDeaths += ev.Deaths
+				}
+			}
+
+			if events[0].Type() != qlstats.TypePlayerConnect || events[cfg.Players].Type() != qlstats.TypeMatchStarted {
+				t.Errorf("Expected players to connect before MATCH_STARTED, got %s then %s", events[0].Type(), events[cfg.Players].Type())
+			}
+			if last := events[len(events)-1]; last.Type() != qlstats.TypeMatchReport {
+				t.Errorf("Expected MATCH_REPORT last, got %s", last.Type())
+			}
+			if counts[qlstats.TypePlayerConnect] != cfg.Players || counts[qlstats.TypePlayerStats] != cfg.Players {
+				t.Errorf("Expected %d connects and stats, got %v", cfg.Players, counts)
+			}
+			if kills == 0 || counts[qlstats.TypePlayerMedal] == 0 {
+				t.Errorf("Expected frags and medals, got %v", counts)
+			}
+			if kills != statKills || deaths != statDeaths {
+				t.Errorf("PLAYER_STATS report %d kills and %d deaths, events have %d and %d", statKills, statDeaths, kills, deaths)
+			}
+		})
+	}
+}
+
+func TestClanArenaRounds(t *testing.T) {
+	sim, err := New(Config{GameType: GameTypeCA, Players: 6, RoundLimit: 5, Seed: 3})
+	if err != nil {
+		t.Fatalf("Failed to create simulator: %v", err)
+	}
+	events := sim.Match()
+
+	won := make(map[qlstats.Team]int)
+	rounds := 0
+	var report *qlstats.MatchReport
+	for _, event := range events {
+		switch ev := event.Data.(type) {
+		case *qlstats.RoundOver:
+			rounds++
+			if ev.Round != rounds {
+				t.Errorf("Expected round %d, got %d", rounds, ev.Round)
+			}
+			won[ev.TeamWon]++
+		case *qlstats.MatchReport:
+			report = ev
+		}
+	}
+
+	if won[qlstats.TeamRed] != 5 && won[qlstats.TeamBlue] != 5 {
+		t.Errorf("Expected a team to win 5 rounds, got %v", won)
+	}
+	if report == nil || report.TeamScoreRed != won[qlstats.TeamRed] || report.TeamScoreBlue != won[qlstats.TeamBlue] {
+		t.Errorf("Expected the report to hold the rounds won %v, got %+v", won, report)
+	}
+	if report != nil && report.ExitMsg != "Roundlimit hit." {
+		t.Errorf("Unexpected exit message %q", report.ExitMsg)
+	}
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{GameType: "ctf"},
+		{GameType: GameTypeDuel, Players: 4},
+		{GameType: GameTypeFFA, Players: 1},
+		{GameType: GameTypeTDM, KillsPerMinute: -1},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("Expected an error for %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// Built-in sink types
+const (
+	SinkTypePostgres           = "postgres"            // Raw events table
+	SinkTypePostgresRelational = "postgres_relational" // Normalized match tables
+	SinkTypeFileBackup         = "file_backup"         // JSONL files
+)
+
+// SinkConfig describes a single storage sink of the sinks list
+type SinkConfig struct {
+	Name    string                 `mapstructure:"name"`
+	Type    string                 `mapstructure:"type"`
+	Options map[string]interface{} `mapstructure:"options"`
+}
+
+// SinkFactory creates a storage client from the options of a sinks entry.
+// Options that are not set fall back to the global configuration.
+type SinkFactory func(cfg Config, options map[string]interface{}) (DBClient, error)
+
+var (
+	sinkFactoriesMu sync.RWMutex
+	sinkFactories   = map[string]SinkFactory{
+		SinkTypePostgres:           newPostgresSink,
+		SinkTypePostgresRelational: newRelationalPostgresSink,
+		SinkTypeFileBackup:         newFileBackupSink,
+	}
+)
+
+// RegisterSinkType makes a sink type available to the sinks list. It panics
+// if the type is registered twice.
+func RegisterSinkType(name string, factory SinkFactory) {
+	sinkFactoriesMu.Lock()
+	defer sinkFactoriesMu.Unlock()
+	if factory == nil {
+		panic("sinks: RegisterSinkType factory is nil")
+	}
+	if _, exists := sinkFactories[name]; exists {
+		panic("sinks: RegisterSinkType called twice for type " + name)
+	}
+	sinkFactories[name] = factory
+}
+
+// SinkTypes returns the registered sink types in alphabetical order
+func SinkTypes() []string {
+	sinkFactoriesMu.RLock()
+	defer sinkFactoriesMu.RUnlock()
+	types := make([]string, 0, len(sinkFactories))
+	for name := range sinkFactories {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// newSink creates the storage client of a sinks entry
+func newSink(cfg Config, sink SinkConfig) (DBClient, error) {
+	sinkFactoriesMu.RLock()
+	factory, ok := sinkFactories[sink.Type]
+	sinkFactoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown sink type %q (available: %v)", sink.Type, SinkTypes())
+	}
+	return factory(cfg, sink.Options)
+}
+
+// decodeSinkOptions decodes the options of a sinks entry into out, rejecting
+// options the sink type does not know
+func decodeSinkOptions(options map[string]interface{}, out interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		ErrorUnused:      true,
+		WeaklyTypedInput: true,
+		Result:           out,
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(options); err != nil {
+		return fmt.Errorf("invalid sink options: %w", err)
+	}
+	return nil
+}
+
+// PostgresSinkOptions contains the options of a postgres sink
+type PostgresSinkOptions struct {
+	ConnectionString string `mapstructure:"connection_string"`
+	Table            string `mapstructure:"table"`
+	IdleTimeoutMin   int    `mapstructure:"idle_timeout_min"`
+	InsertMode       string `mapstructure:"insert_mode"`
+	MaxConns         int    `mapstructure:"max_conns"`
+}
+
+// newPostgresSink creates a PostgresClient for a postgres sink
+func newPostgresSink(cfg Config, options map[string]interface{}) (DBClient, error) {
+	opts := PostgresSinkOptions{
+		ConnectionString: cfg.PostgresConnectionString,
+		Table:            cfg.PostgresTable,
+		IdleTimeoutMin:   cfg.PostgresIdleTimeoutMin,
+		InsertMode:       cfg.PostgresInsertMode,
+		MaxConns:         cfg.PostgresMaxConns,
+	}
+	if err := decodeSinkOptions(options, &opts); err != nil {
+		return nil, err
+	}
+
+	cfg.PostgresEnabled = true
+	cfg.PostgresConnectionString = opts.ConnectionString
+	cfg.PostgresTable = opts.Table
+	cfg.PostgresIdleTimeoutMin = opts.IdleTimeoutMin
+	cfg.PostgresInsertMode = opts.InsertMode
+	cfg.PostgresMaxConns = opts.MaxConns
+	return NewPostgresClient(cfg)
+}
+
+// RelationalPostgresSinkOptions contains the options of a postgres_relational sink
+type RelationalPostgresSinkOptions struct {
+	ConnectionString string `mapstructure:"connection_string"`
+	Schema           string `mapstructure:"schema"`
+	IdleTimeoutMin   int    `mapstructure:"idle_timeout_min"`
+	MaxConns         int    `mapstructure:"max_conns"`
+}
+
+// newRelationalPostgresSink creates a RelationalPostgresClient for a
+// postgres_relational sink
+func newRelationalPostgresSink(cfg Config, options map[string]interface{}) (DBClient, error) {
+	opts := RelationalPostgresSinkOptions{
+		ConnectionString: cfg.PostgresConnectionString,
+		Schema:           cfg.PostgresRelationalSchema,
+		IdleTimeoutMin:   cfg.PostgresIdleTimeoutMin,
+		MaxConns:         cfg.PostgresMaxConns,
+	}
+	if err := decodeSinkOptions(options, &opts); err != nil {
+		return nil, err
+	}
+
+	cfg.PostgresRelationalEnabled = true
+	cfg.PostgresConnectionString = opts.ConnectionString
+	cfg.PostgresRelationalSchema = opts.Schema
+	cfg.PostgresIdleTimeoutMin = opts.IdleTimeoutMin
+	cfg.PostgresMaxConns = opts.MaxConns
+	return NewRelationalPostgresClient(cfg)
+}
+
+// FileBackupSinkOptions contains the options of a file_backup sink
+type FileBackupSinkOptions struct {
+	Path                 string `mapstructure:"path"`
+	MaxSizeMB            int    `mapstructure:"max_size_mb"`
+	MaxAgeHours          int    `mapstructure:"max_age_hours"`
+	Compression          string `mapstructure:"compression"`
+	RetentionMaxAgeHours int    `mapstructure:"retention_max_age_hours"`
+	RetentionMaxFiles    int    `mapstructure:"retention_max_files"`
+	RetentionMaxSizeMB   int    `mapstructure:"retention_max_size_mb"`
+}
+
+// newFileBackupSink creates a FileBackupClient for a file_backup sink
+func newFileBackupSink(cfg Config, options map[string]interface{}) (DBClient, error) {
+	opts := FileBackupSinkOptions{
+		Path:                 cfg.FileBackupPath,
+		MaxSizeMB:            cfg.FileBackupMaxSizeMB,
+		MaxAgeHours:          cfg.FileBackupMaxAgeHours,
+		Compression:          cfg.FileBackupCompression,
+		RetentionMaxAgeHours: cfg.FileBackupRetentionMaxAgeHours,
+		RetentionMaxFiles:    cfg.FileBackupRetentionMaxFiles,
+		RetentionMaxSizeMB:   cfg.FileBackupRetentionMaxSizeMB,
+	}
+	if err := decodeSinkOptions(options, &opts); err != nil {
+		return nil, err
+	}
+
+	return NewFileBackupClient(FileBackupConfig{
+		Enabled:     true,
+		BasePath:    opts.Path,
+		MaxFileSize: int64(opts.MaxSizeMB) * 1024 * 1024, // Convert MB to bytes
+		MaxFileAge:  time.Duration(opts.MaxAgeHours) * time.Hour,
+		Compression: opts.Compression,
+		Retention: RetentionPolicy{
+			MaxAge:   time.Duration(opts.RetentionMaxAgeHours) * time.Hour,
+			MaxFiles: opts.RetentionMaxFiles,
+			MaxBytes: int64(opts.RetentionMaxSizeMB) * 1024 * 1024,
+		},
+	})
+}
+
+// validateSinks checks that every sink has a unique name and that file_backup
+// sinks use a known compression
+func validateSinks(cfg Config, sinks []SinkConfig) error {
+	seen := make(map[string]bool, len(sinks))
+	for _, sink := range sinks {
+		if seen[sink.Name] {
+			return fmt.Errorf("duplicate sink name: %s", sink.Name)
+		}
+		seen[sink.Name] = true
+
+		if sink.Type == SinkTypeFileBackup {
+			// Other invalid options are reported when the sink is created
+			opts := FileBackupSinkOptions{Compression: cfg.FileBackupCompression}
+			if decodeSinkOptions(sink.Options, &opts) == nil && opts.Compression != "" {
+				if err := validateFileCompression(opts.Compression); err != nil {
+					return fmt.Errorf("sink %s: %w", sink.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigSinks(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+postgres_table: events
+sinks:
+  - name: main
+    type: postgres
+    options:
+      connection_string: postgresql://main/quake_stats
+      insert_mode: copy
+  - type: file_backup
+    options:
+      path: /data/events
+      max_size_mb: 50
+`
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config := loadConfigFile(configFile)
+
+	if len(config.Sinks) != 2 {
+		t.Fatalf("Expected 2 sinks, got %d", len(config.Sinks))
+	}
+	if config.Sinks[0].Name != "main" || config.Sinks[0].Options["insert_mode"] != "copy" {
+		t.Errorf("Unexpected first sink: %+v", config.Sinks[0])
+	}
+
+	// Sinks without a name are named after their type
+	if config.Sinks[1].Name != SinkTypeFileBackup {
+		t.Errorf("Expected unnamed sink to use its type as name, got %s", config.Sinks[1].Name)
+	}
+
+	// Options override the global settings, which fill in the rest
+	opts := PostgresSinkOptions{Table: config.PostgresTable}
+	if err := decodeSinkOptions(config.Sinks[0].Options, &opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opts.ConnectionString != "postgresql://main/quake_stats" || opts.Table != "events" {
+		t.Errorf("Unexpected postgres options: %+v", opts)
+	}
+}
+
+func TestConfigSinksFallback(t *testing.T) {
+	config := Config{PostgresEnabled: true, FileBackupEnabled: true}
+
+	sinks := config.SinkList()
+	if len(sinks) != 2 || sinks[0].Type != SinkTypePostgres || sinks[1].Type != SinkTypeFileBackup {
+		t.Fatalf("Expected postgres and file_backup sinks, got %+v", sinks)
+	}
+
+	if sinks := (Config{}).SinkList(); len(sinks) != 0 {
+		t.Errorf("Expected no sinks when storage is disabled, got %+v", sinks)
+	}
+}
+
+func TestSinksMultipleFileDirectories(t *testing.T) {
+	dirs := []string{t.TempDir(), t.TempDir()}
+	cfg := Config{Sinks: []SinkConfig{
+		{Name: "local", Type: SinkTypeFileBackup, Options: map[string]interface{}{"path": dirs[0]}},
+		{Name: "archive", Type: SinkTypeFileBackup, Options: map[string]interface{}{"path": dirs[1]}},
+	}}
+
+	client := newStorageClient(cfg, nil)
+	if client == nil {
+		t.Fatal("Expected a storage client")
+	}
+	if err := client.StoreEvents([]Event{sequencedEvent(1)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Unexpected error closing: %v", err)
+	}
+
+	for _, dir := range dirs {
+		files, _ := filepath.Glob(filepath.Join(dir, "*"))
+		if len(files) == 0 {
+			t.Errorf("Expected a backup file in %s", dir)
+		}
+	}
+}
+
+func TestSinkRegistry(t *testing.T) {
+	var gotOptions map[string]interface{}
+	RegisterSinkType("test_sink", func(cfg Config, options map[string]interface{}) (DBClient, error) {
+		gotOptions = options
+		return &mockDBClient{}, nil
+	})
+
+	client, err := newSink(Config{}, SinkConfig{Type: "test_sink", Options: map[string]interface{}{"url": "http://sink"}})
+	if err != nil || client == nil {
+		t.Fatalf("Expected a client from the registered factory, got %v", err)
+	}
+	if gotOptions["url"] != "http://sink" {
+		t.Errorf("Expected the options to be passed to the factory, got %v", gotOptions)
+	}
+
+	if _, err := newSink(Config{}, SinkConfig{Type: "missing"}); err == nil || !strings.Contains(err.Error(), "test_sink") {
+		t.Errorf("Expected an unknown type error listing the available types, got %v", err)
+	}
+
+	// Misspelled options are rejected instead of silently ignored
+	_, err = newSink(Config{}, SinkConfig{Type: SinkTypeFileBackup, Options: map[string]interface{}{"pth": t.TempDir()}})
+	if err == nil {
+		t.Error("Expected an error for an unknown option")
+	}
+}
+
+func TestValidateSinks(t *testing.T) {
+	cfg := Config{Sinks: []SinkConfig{{Type: SinkTypeFileBackup}, {Type: SinkTypeFileBackup}}}
+	if err := validateSinks(cfg, cfg.SinkList()); err == nil {
+		t.Error("Expected an error for two sinks with the same name")
+	}
+
+	// Compression is checked for the sink options and the file_backup settings
+	cfg = Config{Sinks: []SinkConfig{{Type: SinkTypeFileBackup, Options: map[string]interface{}{"compression": "zstd"}}}}
+	if err := validateSinks(cfg, cfg.SinkList()); err == nil {
+		t.Error("Expected an error for an unknown sink compression")
+	}
+	cfg = Config{FileBackupEnabled: true, FileBackupCompression: "gz"}
+	if err := validateSinks(cfg, cfg.SinkList()); err == nil {
+		t.Error("Expected an error for an unknown file_backup_compression")
+	}
+	cfg.FileBackupCompression = FileCompressionGzip
+	if err := validateSinks(cfg, cfg.SinkList()); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
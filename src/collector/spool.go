@@ -0,0 +1,419 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// spoolSegmentPattern matches committed spool segments
+const spoolSegmentPattern = "batch_*.jsonl"
+
+// ErrSpoolFull is returned by Append when a batch does not fit in the spool
+var ErrSpoolFull = errors.New("spool is full")
+
+// SpoolConfig contains configuration for the failed batch spool
+type SpoolConfig struct {
+	Path       string
+	MaxBytes   int64 // Maximum total size of the spool
+	DropOldest bool  // Drop the oldest batches to make room instead of rejecting new ones
+}
+
+// Spool is a write-ahead directory of batches that failed to store.
+// Each batch is written to its own segment file, which is only removed
+// once the batch has been replayed successfully.
+type Spool struct {
+	dir        string
+	maxBytes   int64
+	dropOldest bool
+	mu         sync.Mutex
+	replayMu   sync.Mutex // Held by ReplayN so only one caller replays at a time
+	segments   []spoolSegment
+	nextSeq    uint64
+	size       int64
+	full       bool // The last batch was rejected and no batch was replayed since
+	// Metrics
+	batchesSpooled  int64
+	eventsSpooled   int64
+	batchesReplayed int64
+	eventsReplayed  int64
+	batchesDropped  int64
+	eventsDropped   int64
+	batchesRejected int64
+	eventsRejected  int64
+	replayErrors    int64
+	lastReplayError string
+	lastReplayTime  time.Time
+}
+
+// spoolSegment describes a committed batch file
+type spoolSegment struct {
+	path   string
+	seq    uint64
+	size   int64
+	events int
+}
+
+// NewSpool opens the spool directory, removing partially written segments
+// and picking up batches left over from a previous run
+func NewSpool(config SpoolConfig) (*Spool, error) {
+	if config.Path == "" {
+		config.Path = "spool"
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = 100 * 1024 * 1024 // 100MB default
+	}
+
+	if err := os.MkdirAll(config.Path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	s := &Spool{
+		dir:        config.Path,
+		maxBytes:   config.MaxBytes,
+		dropOldest: config.DropOldest,
+		nextSeq:    1,
+	}
+
+	// Temporary files are batches that were never committed, e.g. after a crash
+	tmpFiles, err := filepath.Glob(filepath.Join(s.dir, "*.tmp"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan spool directory: %w", err)
+	}
+	for _, tmp := range tmpFiles {
+		log.Printf("Removing incomplete spool file: %s", tmp)
+		os.Remove(tmp)
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.dir, spoolSegmentPattern))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan spool directory: %w", err)
+	}
+	for _, file := range files {
+		var seq uint64
+		if _, err := fmt.Sscanf(filepath.Base(file), "batch_%d.jsonl", &seq); err != nil {
+			log.Printf("Warning: Ignoring unexpected spool file: %s", file)
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat spool file %s: %w", file, err)
+		}
+		s.segments = append(s.segments, spoolSegment{path: file, seq: seq, size: info.Size()})
+		s.size += info.Size()
+		if seq >= s.nextSeq {
+			s.nextSeq = seq + 1
+		}
+	}
+	sort.Slice(s.segments, func(i, j int) bool {
+		return s.segments[i].seq < s.segments[j].seq
+	})
+
+	if len(s.segments) > 0 {
+		log.Printf("Spool %s has %d pending batches (%d bytes) from a previous run", s.dir, len(s.segments), s.size)
+	}
+	return s, nil
+}
+
+// Append durably writes a batch to the spool. The batch is written to a
+// temporary file, synced and then renamed so a crash never leaves a
+// partially written segment behind. When the batch does not fit, Append
+// returns ErrSpoolFull, unless the spool drops its oldest batches to make room.
+func (s *Spool) Append(events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, event := range events {
+		data, err := json.Marshal(newBackupRecord(event))
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := int64(buf.Len())
+	if size > s.maxBytes {
+		s.reject(len(events))
+		return fmt.Errorf("%w: batch of %d bytes exceeds spool size limit of %d bytes", ErrSpoolFull, size, s.maxBytes)
+	}
+
+	if s.size+size > s.maxBytes {
+		if !s.dropOldest {
+			s.reject(len(events))
+			return fmt.Errorf("%w: %d of %d bytes used by %d pending batches", ErrSpoolFull, s.size, s.maxBytes, len(s.segments))
+		}
+		// Make room by dropping the oldest batches
+		for s.size+size > s.maxBytes && len(s.segments) > 0 {
+			s.dropOldestLocked()
+		}
+	}
+
+	seq := s.nextSeq
+	path := filepath.Join(s.dir, fmt.Sprintf("batch_%020d.jsonl", seq))
+	tmpPath := path + ".tmp"
+
+	if err := writeFileSync(tmpPath, buf.Bytes()); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to commit spool file: %w", err)
+	}
+	syncDir(s.dir)
+
+	s.nextSeq++
+	s.segments = append(s.segments, spoolSegment{path: path, seq: seq, size: size, events: len(events)})
+	s.size += size
+	s.batchesSpooled++
+	s.eventsSpooled += int64(len(events))
+
+	log.Printf("Spooled batch of %d events to %s", len(events), path)
+	return nil
+}
+
+// Replay stores spooled batches in order, removing each one once stored.
+// It stops at the first failure so ordering is preserved.
+func (s *Spool) Replay(dbClient DBClient) (int, error) {
+	return s.ReplayN(dbClient, 0)
+}
+
+// ReplayN is like Replay but stores at most max batches, or all of them when
+// max is 0. Each batch is read under the lock and stored outside it, so
+// Append and Pending do not wait for the storage client. The batch is only
+// removed once it is stored.
+func (s *Spool) ReplayN(dbClient DBClient, max int) (int, error) {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	s.mu.Lock()
+	s.lastReplayTime = time.Now()
+	s.mu.Unlock()
+
+	replayed := 0
+	for max <= 0 || replayed < max {
+		s.mu.Lock()
+		segment, events, ok := s.oldestLocked()
+		s.mu.Unlock()
+		if !ok {
+			break
+		}
+
+		if err := dbClient.StoreEvents(events); err != nil {
+			s.mu.Lock()
+			s.replayErrors++
+			s.lastReplayError = err.Error()
+			s.mu.Unlock()
+			return replayed, fmt.Errorf("failed to replay spooled batch %s: %w", filepath.Base(segment.path), err)
+		}
+
+		s.mu.Lock()
+		// Append may have dropped the segment to make room while it was stored
+		if len(s.segments) > 0 && s.segments[0].seq == segment.seq {
+			s.removeOldestLocked(len(events))
+		}
+		s.mu.Unlock()
+		replayed++
+	}
+
+	if replayed > 0 {
+		syncDir(s.dir)
+		log.Printf("Replayed %d spooled batches", replayed)
+	}
+	return replayed, nil
+}
+
+// oldestLocked reads the oldest segment, dropping unreadable ones. It returns
+// false when the spool is empty. Must be called with the lock held.
+func (s *Spool) oldestLocked() (spoolSegment, []Event, bool) {
+	for len(s.segments) > 0 {
+		segment := s.segments[0]
+		events, err := readSpoolSegment(segment.path)
+		if err != nil {
+			// An unreadable segment can never be replayed, so drop it
+			log.Printf("Error reading spool file %s, dropping it: %v", segment.path, err)
+			s.dropOldestLocked()
+			continue
+		}
+		return segment, events, true
+	}
+	return spoolSegment{}, nil, false
+}
+
+// Pop removes the oldest batch from the spool and returns it, or nil when
+// the spool is empty. The lock is only held while the batch is read and
+// removed, so Append does not wait while the caller handles the batch.
+// Unlike Replay, a batch is gone once popped. The removal is not synced, so
+// after a crash a popped batch may be returned again.
+func (s *Spool) Pop() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, events, ok := s.oldestLocked()
+	if !ok {
+		return nil
+	}
+	s.lastReplayTime = time.Now()
+	s.removeOldestLocked(len(events))
+	return events
+}
+
+// removeOldestLocked removes the oldest segment once its events were
+// replayed. Must be called with the lock held.
+func (s *Spool) removeOldestLocked(events int) {
+	segment := s.segments[0]
+	if err := os.Remove(segment.path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: Failed to remove replayed spool file %s: %v", segment.path, err)
+	}
+	s.segments = s.segments[1:]
+	s.size -= segment.size
+	s.batchesReplayed++
+	s.eventsReplayed += int64(events)
+	s.full = false
+}
+
+// Pending returns the number of batches waiting to be replayed
+func (s *Spool) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.segments)
+}
+
+// Full reports whether the last batch was rejected because the spool was
+// full and no batch was replayed since
+func (s *Spool) Full() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.full
+}
+
+// Dropped returns the number of batches and events that were rejected or
+// dropped because the spool was full, or because a segment was unreadable
+func (s *Spool) Dropped() (batches, events int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.batchesDropped + s.batchesRejected, s.eventsDropped + s.eventsRejected
+}
+
+// reject counts a batch that did not fit. Must be called with the lock held.
+func (s *Spool) reject(events int) {
+	s.full = true
+	s.batchesRejected++
+	s.eventsRejected += int64(events)
+}
+
+// dropOldestLocked removes the oldest segment. Must be called with the lock held.
+func (s *Spool) dropOldestLocked() {
+	segment := s.segments[0]
+	events := segment.events
+	if events == 0 {
+		// Segment from a previous run, count its lines
+		if loaded, err := readSpoolSegment(segment.path); err == nil {
+			events = len(loaded)
+		}
+	}
+
+	log.Printf("Warning: Dropping spooled batch %s (%d events) to stay within spool size limit", segment.path, events)
+	os.Remove(segment.path)
+	s.segments = s.segments[1:]
+	s.size -= segment.size
+	s.batchesDropped++
+	s.eventsDropped += int64(events)
+}
+
+// GetMetrics returns metrics about the spool
+func (s *Spool) GetMetrics() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metrics := map[string]interface{}{
+		"path":             s.dir,
+		"pending_batches":  len(s.segments),
+		"pending_bytes":    s.size,
+		"max_bytes":        s.maxBytes,
+		"batches_spooled":  s.batchesSpooled,
+		"events_spooled":   s.eventsSpooled,
+		"batches_replayed": s.batchesReplayed,
+		"events_replayed":  s.eventsReplayed,
+		"batches_dropped":  s.batchesDropped,
+		"events_dropped":   s.eventsDropped,
+		"batches_rejected": s.batchesRejected,
+		"events_rejected":  s.eventsRejected,
+		"full":             s.full,
+		"replay_errors":    s.replayErrors,
+	}
+
+	if s.lastReplayError != "" {
+		metrics["last_replay_error"] = s.lastReplayError
+	}
+	if !s.lastReplayTime.IsZero() {
+		metrics["last_replay_time"] = s.lastReplayTime.Format(time.RFC3339)
+	}
+
+	return metrics
+}
+
+// readSpoolSegment reads all events from a spool segment
+func readSpoolSegment(path string) ([]Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record backupRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("failed to decode spooled event: %w", err)
+		}
+		events = append(events, record.Event())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// writeFileSync writes data to a new file and syncs it to disk
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", path, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync file %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// syncDir syncs a directory so renames and removals survive a crash
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	d.Sync()
+}
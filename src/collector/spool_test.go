@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func spoolTestEvents(types ...string) []Event {
+	events := make([]Event, 0, len(types))
+	for _, t := range types {
+		events = append(events, Event{Type: t, Data: json.RawMessage(`{}`)})
+	}
+	return events
+}
+
+func TestSpoolReplayInOrder(t *testing.T) {
+	spool, err := NewSpool(SpoolConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create spool: %v", err)
+	}
+
+	spool.Append(spoolTestEvents("MATCH_STARTED"))
+	spool.Append(spoolTestEvents("PLAYER_KILL", "PLAYER_DEATH"))
+
+	// A failing client keeps every batch spooled
+	failing := &mockDBClient{storeEventsFunc: func(events []Event) error {
+		return errors.New("database unavailable")
+	}}
+	if _, err := spool.Replay(failing); err == nil {
+		t.Fatal("Expected replay to fail")
+	}
+	if spool.Pending() != 2 {
+		t.Fatalf("Expected 2 pending batches, got %d", spool.Pending())
+	}
+
+	var stored []string
+	healthy := &mockDBClient{storeEventsFunc: func(events []Event) error {
+		for _, e := range events {
+			stored = append(stored, e.Type)
+		}
+		return nil
+	}}
+	replayed, err := spool.Replay(healthy)
+	if err != nil {
+		t.Fatalf("Failed to replay spool: %v", err)
+	}
+
+	if replayed != 2 || spool.Pending() != 0 {
+		t.Fatalf("Expected 2 replayed batches and an empty spool, got %d replayed and %d pending", replayed, spool.Pending())
+	}
+
+	expected := []string{"MATCH_STARTED", "PLAYER_KILL", "PLAYER_DEATH"}
+	for i, typ := range expected {
+		if stored[i] != typ {
+			t.Fatalf("Expected events in order %v, got %v", expected, stored)
+		}
+	}
+}
+
+func TestSpoolReplayOutsideLock(t *testing.T) {
+	spool, err := NewSpool(SpoolConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create spool: %v", err)
+	}
+	spool.Append(spoolTestEvents("MATCH_STARTED"))
+	spool.Append(spoolTestEvents("PLAYER_KILL"))
+
+	// The client appends while a batch is replayed, which would deadlock if
+	// the lock were held
+	client := &mockDBClient{storeEventsFunc: func(events []Event) error {
+		if spool.Pending() == 2 {
+			if err := spool.Append(spoolTestEvents("MATCH_REPORT")); err != nil {
+				t.Errorf("Failed to append during replay: %v", err)
+			}
+		}
+		return nil
+	}}
+	replayed, err := spool.ReplayN(client, 1)
+	if err != nil || replayed != 1 {
+		t.Fatalf("Expected 1 replayed batch, got %d: %v", replayed, err)
+	}
+	if spool.Pending() != 2 {
+		t.Fatalf("Expected 2 pending batches, got %d", spool.Pending())
+	}
+	if events := spool.Pop(); len(events) != 1 || events[0].Type != "PLAYER_KILL" {
+		t.Fatalf("Expected PLAYER_KILL to be next, got %+v", events)
+	}
+}
+
+func TestSpoolPop(t *testing.T) {
+	spool, err := NewSpool(SpoolConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create spool: %v", err)
+	}
+
+	spool.Append(spoolTestEvents("MATCH_STARTED", "PLAYER_KILL"))
+	spool.Append(spoolTestEvents("MATCH_REPORT"))
+
+	if events := spool.Pop(); len(events) != 2 || events[0].Type != "MATCH_STARTED" {
+		t.Fatalf("Expected the oldest batch first, got %+v", events)
+	}
+	if events := spool.Pop(); len(events) != 1 || events[0].Type != "MATCH_REPORT" {
+		t.Fatalf("Expected the second batch, got %+v", events)
+	}
+	if events := spool.Pop(); events != nil || spool.Pending() != 0 {
+		t.Errorf("Expected an empty spool, got %+v", events)
+	}
+}
+
+func TestSpoolSizeLimit(t *testing.T) {
+	spool, err := NewSpool(SpoolConfig{Path: t.TempDir(), MaxBytes: 200})
+	if err != nil {
+		t.Fatalf("Failed to create spool: %v", err)
+	}
+
+	// Batches that do not fit are rejected, the spooled ones are kept
+	var rejected int
+	for i := 0; i < 5; i++ {
+		if err := spool.Append(spoolTestEvents("PLAYER_KILL")); errors.Is(err, ErrSpoolFull) {
+			rejected++
+		} else if err != nil {
+			t.Fatalf("Failed to append batch: %v", err)
+		}
+	}
+	if rejected == 0 || !spool.Full() {
+		t.Fatal("Expected batches to be rejected once the spool is full")
+	}
+	if spool.Pending() != 5-rejected {
+		t.Errorf("Expected %d pending batches, got %d", 5-rejected, spool.Pending())
+	}
+	if batches, events := spool.Dropped(); batches != int64(rejected) || events != int64(rejected) {
+		t.Errorf("Expected %d dropped batches and events, got %d and %d", rejected, batches, events)
+	}
+
+	// Replaying makes room again
+	if _, err := spool.Replay(&mockDBClient{}); err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if spool.Full() {
+		t.Error("Expected the spool to accept batches after a replay")
+	}
+}
+
+func TestSpoolDropOldest(t *testing.T) {
+	spool, err := NewSpool(SpoolConfig{Path: t.TempDir(), MaxBytes: 200, DropOldest: true})
+	if err != nil {
+		t.Fatalf("Failed to create spool: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := spool.Append(spoolTestEvents("PLAYER_KILL")); err != nil {
+			t.Fatalf("Failed to append batch: %v", err)
+		}
+	}
+
+	metrics := spool.GetMetrics()
+	if metrics["pending_bytes"].(int64) > 200 {
+		t.Errorf("Spool exceeds size limit: %v bytes", metrics["pending_bytes"])
+	}
+	if metrics["batches_dropped"].(int64) == 0 {
+		t.Error("Expected the oldest batches to be dropped")
+	}
+}
+
+func TestSpoolRecoversAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(SpoolConfig{Path: dir})
+	if err != nil {
+		t.Fatalf("Failed to create spool: %v", err)
+	}
+	spool.Append(spoolTestEvents("MATCH_STARTED"))
+
+	// Simulate a crash in the middle of writing a batch
+	if err := os.WriteFile(filepath.Join(dir, "batch_00000000000000000002.jsonl.tmp"), []byte(`{"type":"PLA`), 0644); err != nil {
+		t.Fatalf("Failed to write partial file: %v", err)
+	}
+
+	reopened, err := NewSpool(SpoolConfig{Path: dir})
+	if err != nil {
+		t.Fatalf("Failed to reopen spool: %v", err)
+	}
+	if reopened.Pending() != 1 {
+		t.Fatalf("Expected 1 pending batch after restart, got %d", reopened.Pending())
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmp) != 0 {
+		t.Errorf("Expected incomplete files to be removed, found %v", tmp)
+	}
+
+	// New batches must sort after the recovered one
+	reopened.Append(spoolTestEvents("PLAYER_KILL"))
+	var stored []string
+	reopened.Replay(&mockDBClient{storeEventsFunc: func(events []Event) error {
+		stored = append(stored, events[0].Type)
+		return nil
+	}})
+	if len(stored) != 2 || stored[0] != "MATCH_STARTED" || stored[1] != "PLAYER_KILL" {
+		t.Errorf("Unexpected replay order after restart: %v", stored)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"quake-stats/qlstats"
+)
+
+// RejectHandler is implemented by processors that quarantine payloads the
+// collector could not parse into an Event
+type RejectHandler interface {
+	Reject(raw []byte, event Event, reason string, err error)
+}
+
+// EventValidator checks events before they are submitted to the processor.
+// Rejected payloads are sent to the dead-letter sink instead of storage.
+// Events of a known type whose data does not decode into the typed model are
+// still stored, so one unexpected field does not quarantine valid events.
+type EventValidator struct {
+	next       EventProcessorInterface
+	deadLetter DeadLetterSink
+	metrics    *CollectorMetrics
+	mu         sync.Mutex
+	accepted   int64
+	invalid    int64 // Accepted events whose data did not decode
+	rejected   map[string]int64
+	lastSeen   map[string]time.Time // Last message received per server
+}
+
+// NewEventValidator creates a validator in front of the given processor.
+// The dead-letter sink may be nil, in which case rejected payloads are only logged.
+func NewEventValidator(next EventProcessorInterface, deadLetter DeadLetterSink) *EventValidator {
+	return &EventValidator{
+		next:       next,
+		deadLetter: deadLetter,
+		rejected:   make(map[string]int64),
+		lastSeen:   make(map[string]time.Time),
+	}
+}
+
+// SetMetrics counts received, decoded and rejected messages per server in
+// the given metrics
+func (v *EventValidator) SetMetrics(m *CollectorMetrics) {
+	v.metrics = m
+}
+
+// Run implements the EventProcessorInterface interface
+func (v *EventValidator) Run(ctx context.Context) {
+	v.next.Run(ctx)
+}
+
+// ProcessEvent validates an event and forwards it to the processor
+func (v *EventValidator) ProcessEvent(e Event) {
+	v.received(e.Server)
+	if reason, err := ValidateEvent(e); err != nil {
+		v.Reject(nil, e, reason, err)
+		return
+	}
+
+	_, decodeErr := e.Decode()
+
+	v.mu.Lock()
+	v.accepted++
+	if decodeErr != nil {
+		v.invalid++
+	}
+	v.mu.Unlock()
+
+	if decodeErr != nil {
+		log.Printf("[%s] Warning: Storing %s event that does not decode: %v", e.Server, e.Type, decodeErr)
+		v.metrics.eventInvalid(e.Server, e.Type)
+	} else {
+		v.metrics.eventDecoded(e.Server)
+	}
+
+	v.next.ProcessEvent(e)
+}
+
+// received records a message received from a server, valid or not
+func (v *EventValidator) received(server string) {
+	v.mu.Lock()
+	v.lastSeen[server] = time.Now()
+	v.mu.Unlock()
+	v.metrics.eventReceived(server)
+}
+
+// LastReceived returns the time a message was last received from the server,
+// or the zero time if none was received yet
+func (v *EventValidator) LastReceived(server string) time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.lastSeen[server]
+}
+
+// Reject sends a payload to the dead-letter sink. When raw is nil the
+// payload is rebuilt from the event.
+func (v *EventValidator) Reject(raw []byte, e Event, reason string, err error) {
+	v.mu.Lock()
+	v.rejected[reason]++
+	v.mu.Unlock()
+
+	// Payloads rejected by the collector itself never went through ProcessEvent
+	if raw != nil {
+		v.received(e.Server)
+	}
+	v.metrics.eventFailed(e.Server, reason)
+
+	if raw == nil {
+		raw, _ = json.Marshal(e)
+	}
+
+	log.Printf("[%s] Rejected event (%s): %v", e.Server, reason, err)
+
+	if v.deadLetter == nil {
+		return
+	}
+
+	letter := DeadLetter{
+		ID:         e.ID,
+		RejectedAt: time.Now().UTC(),
+		ReceivedAt: e.ReceivedAt,
+		Server:     e.Server,
+		Sequence:   e.Sequence,
+		Type:       e.Type,
+		Reason:     reason,
+		Raw:        raw,
+	}
+	if letter.ID == "" {
+		letter.ID = newEventID()
+	}
+	if err != nil {
+		letter.Error = err.Error()
+	}
+
+	if writeErr := v.deadLetter.Write(letter); writeErr != nil {
+		log.Printf("Error writing dead letter: %v", writeErr)
+	}
+}
+
+// GetMetrics returns validation counters
+func (v *EventValidator) GetMetrics() map[string]interface{} {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	metrics := map[string]interface{}{
+		"accepted":     v.accepted,
+		"invalid_data": v.invalid,
+	}
+	var total int64
+	for reason, count := range v.rejected {
+		metrics["rejected_"+reason] = count
+		total += count
+	}
+	metrics["rejected"] = total
+	return metrics
+}
+
+// ValidateEvent checks that an event has a known type and data. It returns
+// the rejection reason on failure. Whether the data decodes into the typed
+// model is not checked.
+func ValidateEvent(e Event) (string, error) {
+	if e.Type == "" {
+		return RejectEmptyType, errors.New("event has no TYPE")
+	}
+	if !qlstats.KnownType(e.Type) {
+		return RejectUnknownType, fmt.Errorf("unknown event type %q", e.Type)
+	}
+	if len(e.Data) == 0 || string(e.Data) == "null" {
+		return RejectMissingData, fmt.Errorf("%s event has no DATA", e.Type)
+	}
+	return "", nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	zmq4 "github.com/pebbe/zmq4"
@@ -13,15 +14,23 @@ import (
 
 // ZmqCollector represents a ZMQ event collector
 type ZmqCollector struct {
+	server     string
 	endpoint   string
 	socket     *zmq4.Socket
 	processor  EventProcessorInterface
 	isRemote   bool
 	cancelFunc context.CancelFunc
+	authFailed chan struct{}
+	sequence   uint64
 }
 
-// NewZmqCollector creates a new ZMQ collector
-func NewZmqCollector(endpoint string, processor EventProcessorInterface) (*ZmqCollector, error) {
+// monitorCount is used to give each socket monitor a unique inproc address
+var monitorCount int64
+
+// NewZmqCollector creates a new ZMQ collector for the given server
+func NewZmqCollector(server ServerConfig, processor EventProcessorInterface) (*ZmqCollector, error) {
+	endpoint := server.Endpoint
+
 	// Validate endpoint format - ZMQ endpoints must start with a valid protocol
 	validProtocols := []string{"tcp://", "ipc://", "inproc://", "pgm://", "epgm://"}
 	isValidProtocol := false
@@ -63,28 +72,68 @@ func NewZmqCollector(endpoint string, processor EventProcessorInterface) (*ZmqCo
 		return nil, fmt.Errorf("failed to set linger: %w", err)
 	}
 
+	// Apply per-server socket options, falling back to defaults
+	receiveTimeout := 500 * time.Millisecond
+	if server.ReceiveTimeoutMs > 0 {
+		receiveTimeout = time.Duration(server.ReceiveTimeoutMs) * time.Millisecond
+	}
+	reconnectIvl := 1 * time.Second
+	if server.ReconnectIntervalSec > 0 {
+		reconnectIvl = time.Duration(server.ReconnectIntervalSec) * time.Second
+	}
+	reconnectIvlMax := 10 * time.Second
+	if server.ReconnectIntervalMaxSec > 0 {
+		reconnectIvlMax = time.Duration(server.ReconnectIntervalMaxSec) * time.Second
+	}
+
 	// Only need a short timeout since we expect regular messages
-	if err := socket.SetRcvtimeo(500 * time.Millisecond); err != nil {
+	if err := socket.SetRcvtimeo(receiveTimeout); err != nil {
 		socket.Close()
 		return nil, fmt.Errorf("failed to set receive timeout: %w", err)
 	}
 
+	// Queue more messages in the socket before ZMQ starts dropping them
+	if server.ReceiveHWM > 0 {
+		if err := socket.SetRcvhwm(server.ReceiveHWM); err != nil {
+			socket.Close()
+			return nil, fmt.Errorf("failed to set receive high water mark: %w", err)
+		}
+	}
+
 	// Set socket reconnect options
-	if err := socket.SetReconnectIvl(1 * time.Second); err != nil {
+	if err := socket.SetReconnectIvl(reconnectIvl); err != nil {
 		socket.Close()
 		return nil, fmt.Errorf("failed to set reconnect interval: %w", err)
 	}
 	
-	if err := socket.SetReconnectIvlMax(10 * time.Second); err != nil {
+	if err := socket.SetReconnectIvlMax(reconnectIvlMax); err != nil {
 		socket.Close()
 		return nil, fmt.Errorf("failed to set max reconnect interval: %w", err)
 	}
 
+	// Configure ZMQ PLAIN authentication for password-protected stats sockets
+	if server.Password != "" {
+		username := server.Username
+		if username == "" {
+			username = defaultZmqUsername
+		}
+		if err := socket.SetPlainUsername(username); err != nil {
+			socket.Close()
+			return nil, fmt.Errorf("failed to set PLAIN username: %w", err)
+		}
+		if err := socket.SetPlainPassword(server.Password); err != nil {
+			socket.Close()
+			return nil, fmt.Errorf("failed to set PLAIN password: %w", err)
+		}
+	}
+
 	return &ZmqCollector{
+		server:     server.Name,
 		endpoint:   endpoint,
 		socket:     socket,
 		processor:  processor,
 		isRemote:   isRemoteConnection,
+		authFailed: make(chan struct{}, 1),
 	}, nil
 }
 
@@ -93,6 +142,7 @@ func (c *ZmqCollector) Run(ctx context.Context) error {
 	// Create a new context that we can cancel if Stop is called
 	runCtx, cancel := context.WithCancel(ctx)
 	c.cancelFunc = cancel
+	defer cancel()
 	
 	// Start the collector and return any error
 	return c.Start(runCtx)
@@ -108,7 +158,12 @@ func (c *ZmqCollector) Stop() {
 
 // Start begins collecting events from ZMQ
 func (c *ZmqCollector) Start(ctx context.Context) error {
-	log.Printf("Connecting to ZMQ endpoint: %s", c.endpoint)
+	log.Printf("[%s] Connecting to ZMQ endpoint: %s", c.server, c.endpoint)
+	
+	// Watch the handshake so authentication failures can be reported
+	if err := c.startMonitor(ctx); err != nil {
+		log.Printf("[%s] Warning: Failed to monitor ZMQ socket: %v", c.server, err)
+	}
 	
 	err := c.socket.Connect(c.endpoint)
 	if err != nil {
@@ -129,9 +184,19 @@ func (c *ZmqCollector) Start(ctx context.Context) error {
 	lastLog := time.Now()
 	
 	for ctx.Err() == nil {
+		// Stop if the server rejected our credentials
+		select {
+		case <-c.authFailed:
+			return &CollectorError{
+				Message:    fmt.Sprintf("authentication failed for ZMQ endpoint '%s'", c.endpoint),
+				AuthFailed: true,
+			}
+		default:
+		}
+		
 		// Periodically log that we're still waiting for messages
 		if time.Since(lastLog) > 30*time.Second {
-			log.Printf("Still waiting for messages (received %d so far)...", receivedCount)
+			log.Printf("[%s] Still waiting for messages (received %d so far)...", c.server, receivedCount)
 			lastLog = time.Now()
 		}
 		
@@ -151,7 +216,7 @@ func (c *ZmqCollector) Start(ctx context.Context) error {
 			}
 			
 			// Log other errors
-			log.Printf("ZMQ receive error: %v", err)
+			log.Printf("[%s] ZMQ receive error: %v", c.server, err)
 			time.Sleep(500 * time.Millisecond) // Short delay to avoid log spam
 			continue
 		}
@@ -163,10 +228,24 @@ func (c *ZmqCollector) Start(ctx context.Context) error {
 			continue
 		}
 		
-		var e Event
+		// Wrap the event in the collector-side envelope
+		c.sequence++
+		e := Event{
+			ID:         newEventID(),
+			Server:     c.server,
+			ReceivedAt: time.Now().UTC(),
+			Sequence:   c.sequence,
+		}
+		
 		if err := json.Unmarshal(msg, &e); err != nil {
-			log.Printf("Failed to unmarshal event: %v", err)
-			log.Printf("Raw message: %s", string(msg))
+			log.Printf("[%s] Failed to unmarshal event: %v", c.server, err)
+			
+			// Quarantine the payload if the processor supports it
+			if rejecter, ok := c.processor.(RejectHandler); ok {
+				rejecter.Reject(msg, e, RejectMalformedJSON, err)
+			} else {
+				log.Printf("Raw message: %s", string(msg))
+			}
 			continue
 		}
 
@@ -176,6 +255,50 @@ func (c *ZmqCollector) Start(ctx context.Context) error {
 	return nil
 }
 
+// startMonitor attaches a socket monitor and signals authFailed when the
+// server rejects the PLAIN handshake
+func (c *ZmqCollector) startMonitor(ctx context.Context) error {
+	addr := fmt.Sprintf("inproc://monitor-%d", atomic.AddInt64(&monitorCount, 1))
+	if err := c.socket.Monitor(addr, zmq4.EVENT_HANDSHAKE_FAILED_AUTH); err != nil {
+		return err
+	}
+
+	monitor, err := zmq4.NewSocket(zmq4.PAIR)
+	if err != nil {
+		return err
+	}
+	if err := monitor.SetRcvtimeo(500 * time.Millisecond); err != nil {
+		monitor.Close()
+		return err
+	}
+	if err := monitor.Connect(addr); err != nil {
+		monitor.Close()
+		return err
+	}
+
+	go func() {
+		defer monitor.Close()
+		for ctx.Err() == nil {
+			event, _, _, err := monitor.RecvEvent(0)
+			if err != nil {
+				if err == zmq4.ErrorSocketClosed {
+					return
+				}
+				continue
+			}
+			if event == zmq4.EVENT_HANDSHAKE_FAILED_AUTH {
+				log.Printf("[%s] ZMQ authentication failed", c.server)
+				select {
+				case c.authFailed <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
 // Close closes the ZMQ socket
 func (c *ZmqCollector) Close() {
 	if c.socket != nil {
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/pebbe/zmq4"
+)
+
+// zmqAuthDomain is the ZAP domain of password-protected publishers
+const zmqAuthDomain = "quake-stats"
+
+// EventPublisher publishes events in the Quake Live wire format
+type EventPublisher interface {
+	Publish(e Event) error
+}
+
+// ZmqPublisher publishes events on a PUB socket the way a Quake Live server
+// with zmq_stats_enable does, so a ZmqCollector can subscribe to it
+type ZmqPublisher struct {
+	socket    *zmq4.Socket
+	auth      bool
+	published atomic.Int64
+}
+
+// NewZmqPublisher binds a PUB socket to endpoint. With a password,
+// subscribers must authenticate with ZMQ PLAIN as the stats user. sendHWM
+// overrides the number of messages queued per subscriber when positive.
+func NewZmqPublisher(endpoint, password string, sendHWM int) (*ZmqPublisher, error) {
+	socket, err := zmq4.NewSocket(zmq4.PUB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ZMQ socket: %w", err)
+	}
+
+	// Give queued messages a moment to go out on Close
+	if err := socket.SetLinger(time.Second); err != nil {
+		socket.Close()
+		return nil, fmt.Errorf("failed to set linger: %w", err)
+	}
+
+	if sendHWM > 0 {
+		if err := socket.SetSndhwm(sendHWM); err != nil {
+			socket.Close()
+			return nil, fmt.Errorf("failed to set send high water mark: %w", err)
+		}
+	}
+
+	publisher := &ZmqPublisher{socket: socket}
+	if password != "" {
+		if err := zmq4.AuthStart(); err != nil {
+			socket.Close()
+			return nil, fmt.Errorf("failed to start ZMQ authentication: %w", err)
+		}
+		zmq4.AuthPlainAdd(zmqAuthDomain, defaultZmqUsername, password)
+		publisher.auth = true
+		if err := socket.ServerAuthPlain(zmqAuthDomain); err != nil {
+			publisher.Close()
+			return nil, fmt.Errorf("failed to enable PLAIN authentication: %w", err)
+		}
+	}
+
+	if err := socket.Bind(endpoint); err != nil {
+		publisher.Close()
+		return nil, fmt.Errorf("failed to bind %s: %w", endpoint, err)
+	}
+	return publisher, nil
+}
+
+// Publish sends an event as a {"TYPE": ..., "DATA": ...} message
+func (p *ZmqPublisher) Publish(e Event) error {
+	msg, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	if _, err := p.socket.SendBytes(msg, 0); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	p.published.Add(1)
+	return nil
+}
+
+// Published returns the number of events published
+func (p *ZmqPublisher) Published() int64 {
+	return p.published.Load()
+}
+
+// Close closes the socket
+func (p *ZmqPublisher) Close() error {
+	err := p.socket.Close()
+	if p.auth {
+		zmq4.AuthStop()
+	}
+	return err
+}
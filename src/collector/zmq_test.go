@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"testing"
 	"time"
+
+	zmq4 "github.com/pebbe/zmq4"
 )
 
 // MockEventProcessor implements a simplified event processor for testing
@@ -45,7 +47,7 @@ func (m *MockEventProcessor) GetChannel() chan<- Event {
 func TestZmqCollectorCreate(t *testing.T) {
 	// Test creating a ZMQ collector with valid endpoint
 	processor := NewMockEventProcessor()
-	collector, err := NewZmqCollector("tcp://localhost:5555", processor)
+	collector, err := NewZmqCollector(ServerConfig{Name: "test", Endpoint: "tcp://localhost:5555"}, processor)
 	
 	if err != nil {
 		t.Fatalf("Failed to create ZMQ collector: %v", err)
@@ -59,7 +61,7 @@ func TestZmqCollectorCreate(t *testing.T) {
 func TestZmqCollectorInvalidEndpoint(t *testing.T) {
 	// Test with invalid endpoint
 	processor := NewMockEventProcessor()
-	_, err := NewZmqCollector("invalid://endpoint", processor)
+	_, err := NewZmqCollector(ServerConfig{Name: "test", Endpoint: "invalid://endpoint"}, processor)
 	
 	if err == nil {
 		t.Fatal("Expected error for invalid endpoint, got nil")
@@ -117,7 +119,7 @@ func TestIntegrationWithZmq(t *testing.T) {
 	t.Skip("Skipping integration test that requires a ZMQ server")
 
 	processor := NewMockEventProcessor()
-	collector, err := NewZmqCollector("tcp://localhost:5555", processor) // Test server
+	collector, err := NewZmqCollector(ServerConfig{Name: "test", Endpoint: "tcp://localhost:5555"}, processor) // Test server
 	if err != nil {
 		t.Fatalf("Failed to create ZMQ collector: %v", err)
 	}
@@ -136,4 +138,137 @@ func TestIntegrationWithZmq(t *testing.T) {
 	// Check if any events were processed
 	// Note: This test is dependent on external factors
 	t.Logf("Received %d events", len(processor.events))
-} 
\ No newline at end of file
+} 
+// chanEventProcessor forwards processed events to a channel
+type chanEventProcessor struct {
+	events chan Event
+}
+
+func (c *chanEventProcessor) ProcessEvent(event Event) {
+	c.events <- event
+}
+
+func (c *chanEventProcessor) Run(ctx context.Context) {
+	<-ctx.Done()
+}
+
+func TestZmqCollectorPlainAuth(t *testing.T) {
+	// Start the ZAP handler with the credentials a Quake Live server would accept
+	if err := zmq4.AuthStart(); err != nil {
+		t.Fatalf("Failed to start ZMQ authentication: %v", err)
+	}
+	defer zmq4.AuthStop()
+	zmq4.AuthPlainAdd("quake", "stats", "secret")
+
+	// Create a local password-protected PUB socket
+	endpoint := "tcp://127.0.0.1:27970"
+	pub, err := zmq4.NewSocket(zmq4.PUB)
+	if err != nil {
+		t.Fatalf("Failed to create PUB socket: %v", err)
+	}
+	defer pub.Close()
+	if err := pub.ServerAuthPlain("quake"); err != nil {
+		t.Fatalf("Failed to enable PLAIN authentication: %v", err)
+	}
+	if err := pub.Bind(endpoint); err != nil {
+		t.Fatalf("Failed to bind PUB socket: %v", err)
+	}
+
+	t.Run("valid password", func(t *testing.T) {
+		processor := &chanEventProcessor{events: make(chan Event, 10)}
+		collector, err := NewZmqCollector(ServerConfig{Name: "protected", Endpoint: endpoint, Password: "secret"}, processor)
+		if err != nil {
+			t.Fatalf("Failed to create ZMQ collector: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		go collector.Run(ctx)
+
+		// Keep publishing until the subscriber has joined
+		for {
+			if _, err := pub.SendBytes([]byte(`{"TYPE":"PLAYER_KILL","DATA":{}}`), 0); err != nil {
+				t.Fatalf("Failed to publish event: %v", err)
+			}
+			select {
+			case e := <-processor.events:
+				if e.Type != "PLAYER_KILL" || e.Server != "protected" {
+					t.Errorf("Unexpected event received: %+v", e)
+				}
+				return
+			case <-time.After(100 * time.Millisecond):
+			case <-ctx.Done():
+				t.Fatal("Timed out waiting for event from protected server")
+			}
+		}
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		processor := &chanEventProcessor{events: make(chan Event, 10)}
+		collector, err := NewZmqCollector(ServerConfig{Name: "protected", Endpoint: endpoint, Password: "wrong"}, processor)
+		if err != nil {
+			t.Fatalf("Failed to create ZMQ collector: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		err = collector.Run(ctx)
+		collectorErr, ok := err.(*CollectorError)
+		if !ok {
+			t.Fatalf("Expected a CollectorError, got %v", err)
+		}
+		if !collectorErr.AuthFailed || collectorErr.Recoverable {
+			t.Errorf("Expected a non-recoverable authentication error, got %+v", collectorErr)
+		}
+	})
+}
+
+func TestZmqCollectorEnvelope(t *testing.T) {
+	endpoint := "tcp://127.0.0.1:27971"
+	pub, err := zmq4.NewSocket(zmq4.PUB)
+	if err != nil {
+		t.Fatalf("Failed to create PUB socket: %v", err)
+	}
+	defer pub.Close()
+	if err := pub.Bind(endpoint); err != nil {
+		t.Fatalf("Failed to bind PUB socket: %v", err)
+	}
+
+	processor := &chanEventProcessor{events: make(chan Event, 100)}
+	collector, err := NewZmqCollector(ServerConfig{Name: "ca", Endpoint: endpoint}, processor)
+	if err != nil {
+		t.Fatalf("Failed to create ZMQ collector: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go collector.Run(ctx)
+
+	// Publish until two events have arrived
+	var received []Event
+	for len(received) < 2 {
+		pub.SendBytes([]byte(`{"TYPE":"PLAYER_KILL","DATA":{}}`), 0)
+		select {
+		case e := <-processor.events:
+			received = append(received, e)
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for events")
+		}
+	}
+
+	first, second := received[0], received[1]
+	if first.ID == "" || first.ID == second.ID {
+		t.Errorf("Expected unique event IDs, got %q and %q", first.ID, second.ID)
+	}
+	if first.Server != "ca" {
+		t.Errorf("Expected server ca, got %q", first.Server)
+	}
+	if first.ReceivedAt.IsZero() || second.ReceivedAt.Before(first.ReceivedAt) {
+		t.Errorf("Expected ordered receive times, got %v and %v", first.ReceivedAt, second.ReceivedAt)
+	}
+	if first.Sequence != 1 || second.Sequence != 2 {
+		t.Errorf("Expected sequence numbers 1 and 2, got %d and %d", first.Sequence, second.Sequence)
+	}
+}